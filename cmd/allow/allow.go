@@ -0,0 +1,49 @@
+// Package allow implements the `allow` command
+package allow
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	manager := workspace.New(storageDir, pluginsDir)
+	link, err := manager.Find(dir)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		return fmt.Errorf("%s is not linked to a workspace", dir)
+	}
+	if err := manager.Allow(link); err != nil {
+		return err
+	}
+	fmt.Printf("(%s) workspace is allowed to activate in %s\n", link.Namespace, link.Dir)
+	return nil
+}
+
+// NewCommand returns a new cobra.Command allowing the shell hook to activate
+// the workspace of a directory
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "allow [dir]",
+		Short: "Allows the shell hook to set up and activate the workspace linked to a directory",
+		Long: "Allows the shell hook to set up and activate the workspace named by the .dem file of a directory, " +
+			"which installs the toolchains and runs the hooks of the workspace. Project directories of a workspace are always allowed.",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	return cmd
+}
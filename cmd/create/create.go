@@ -2,40 +2,51 @@
 package create
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/samuelngs/dem/pkg/globalconfig"
-	"github.com/samuelngs/dem/pkg/util/fs"
-	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"github.com/samuelngs/dem/pkg/importer"
+	"github.com/samuelngs/dem/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
+var from string
+
 func createWorkspace(namespace string) error {
 	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
-	workingDir := fmt.Sprintf("%s/%s", storageDir, namespace)
-	configPath := fmt.Sprintf("%s/%s", workingDir, ".workspace.yaml")
-
-	// cancel action if workspace already exists
-	if fs.Exists(configPath) {
-		fmt.Printf("workspace '%s' already exists\n", namespace)
-		return nil
-	}
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
 
-	// initialize workspace directory
-	if err := fs.Mkdir(workingDir); err != nil {
-		return err
+	// the file is translated first, so that no workspace is left behind if
+	// it is invalid
+	var imported *importer.Config
+	if len(from) > 0 {
+		var err error
+		if imported, err = importer.Open(from); err != nil {
+			return err
+		}
 	}
 
-	// initialize default workspace settings
-	b, err := workspaceconfig.New()
-	if err != nil {
+	manager := workspace.New(storageDir, pluginsDir)
+	err := manager.Create(namespace)
+	switch {
+	case errors.Is(err, workspace.ErrExist):
+		// cancel action if workspace already exists
+		fmt.Println(err)
+		return nil
+	case err != nil:
 		return err
 	}
 
-	if err := fs.WriteFile(configPath, b); err != nil {
-		return err
+	if imported != nil {
+		if err := manager.Import(namespace, imported); err != nil {
+			return err
+		}
+		for _, s := range imported.Unmapped {
+			fmt.Fprintf(os.Stderr, "warning: not imported: %s\n", s)
+		}
 	}
 
 	fmt.Printf("workspace '%s' created\n", namespace)
@@ -56,12 +67,13 @@ func run(cmd *cobra.Command, args []string) error {
 // NewCommand returns a new cobra.Command for cluster creation
 func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                   "create [namespace]",
+		Use:                   "create [namespace] [--from file]",
 		Short:                 "Creates an isolated development workspace [name]",
 		Long:                  "Creates a local isolated development workspace",
 		Aliases:               []string{"init", "add", "up"},
 		DisableFlagsInUseLine: true,
 		RunE:                  run,
 	}
+	cmd.Flags().StringVar(&from, "from", "", "import the toolchains of a .tool-versions, devcontainer.json or mise.toml file")
 	return cmd
 }
@@ -2,12 +2,13 @@
 package delete
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/samuelngs/dem/pkg/globalconfig"
-	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -15,22 +16,14 @@ var keepFiles bool
 
 func deleteWorkspace(namespace string) error {
 	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
-	workingDir := fmt.Sprintf("%s/%s", storageDir, namespace)
-	configPath := fmt.Sprintf("%s/%s", workingDir, ".workspace.yaml")
-
-	var path string
-	if keepFiles {
-		path = configPath
-	} else {
-		path = workingDir
-	}
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
 
-	if !fs.Exists(path) {
-		fmt.Printf("workspace '%s' does not exist\n", namespace)
+	err := workspace.New(storageDir, pluginsDir).Delete(namespace, keepFiles)
+	switch {
+	case errors.Is(err, workspace.ErrNotExist):
+		fmt.Println(err)
 		return nil
-	}
-
-	if err := os.Remove(path); err != nil {
+	case err != nil:
 		return err
 	}
 
@@ -2,17 +2,62 @@
 package describe
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
+func describeWorkspace(namespace string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	ws, err := workspace.New(storageDir, pluginsDir).Get(namespace)
+	if err != nil {
+		return err
+	}
+	ws.Diagnostics.Print(os.Stderr)
+	config, exts := ws.Config, ws.Extensions
+
+	shell := config.Workspace.Shell
+	fmt.Printf("namespace    %s\n", namespace)
+	fmt.Printf("directory    %s\n", config.WorkingDir)
+	fmt.Printf("shell        %s\n", strings.TrimSpace(fmt.Sprintf("%s %s", shell.Program, strings.Join(shell.Args, " "))))
+	if len(exts) == 0 {
+		return nil
+	}
+	fmt.Println("extensions")
+	for _, extension := range exts {
+		line := fmt.Sprintf("  %s", extension)
+		if detector, ok := extension.(ext.Detector); ok {
+			if detected := detector.Detected(); detected != nil {
+				line = fmt.Sprintf("%s (detected %s from %s)", line, detected.Version, detected.Source)
+			}
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
 func run(cmd *cobra.Command, args []string) error {
-	return cmd.Usage()
+	switch {
+	case len(args) == 0:
+		return cmd.Usage()
+	case len(strings.TrimSpace(args[0])) == 0:
+		return cmd.Usage()
+	default:
+		return describeWorkspace(args[0])
+	}
 }
 
 // NewCommand returns a new cobra.Command for cluster creation
 func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "describe",
+		Use:   "describe [namespace]",
 		Short: "Show details of a specific workspace",
 		Long:  "Show details of a specific workspace",
 		RunE:  run,
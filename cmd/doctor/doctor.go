@@ -0,0 +1,61 @@
+// Package doctor implements the `doctor` command
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/doctor"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/spf13/cobra"
+)
+
+var output string
+
+// pre replaces the root pre-run, the global config is loaded as one of the
+// checks so that a broken config is reported instead of aborting
+func pre(cmd *cobra.Command, args []string) {}
+
+func run(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmd.Usage()
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid output '%s' (text, json)", output)
+	}
+	report := doctor.Global(cmd.Flag("config").Value.String())
+	if len(args) == 1 {
+		storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+		pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+		doctor.Workspace(report, args[0], storageDir, pluginsDir)
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		report.Print(os.Stdout)
+	}
+	if report.Failed() {
+		return fmt.Errorf("some checks failed")
+	}
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for checking the health of dem
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "doctor [namespace]",
+		Short:            "Check the health of dem and a workspace",
+		Long:             "Check the global config, plugins and optionally the configuration, shell and extensions of a workspace",
+		PersistentPreRun: pre,
+		RunE:             run,
+		SilenceUsage:     true,
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "report format (text, json)")
+	return cmd
+}
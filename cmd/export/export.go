@@ -0,0 +1,166 @@
+// Package export implements the `export` command run by the shell hook
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/hook"
+	"github.com/samuelngs/dem/pkg/util/env"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"github.com/spf13/cobra"
+)
+
+// environ returns the environment of the shell
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, s := range os.Environ() {
+		if parts := strings.SplitN(s, "=", 2); len(parts) == 2 {
+			vars[parts[0]] = parts[1]
+		}
+	}
+	return vars
+}
+
+// modified returns the modification time of the workspace configuration, the
+// environment is exported again once it changes
+func modified(manager *workspace.Manager, namespace string) int64 {
+	info, err := os.Stat(filepath.Join(manager.StorageDir, namespace, ".workspace.yaml"))
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// activation sets up the workspace and returns its variables
+func activation(manager *workspace.Manager, namespace, path string) (map[string]string, error) {
+	ws, err := manager.Get(namespace)
+	if err != nil {
+		return nil, err
+	}
+	ws.Diagnostics.Print(os.Stderr)
+	if ws.Config.Workspace.Network == workspaceconfig.NetworkIsolated || ws.Config.Workspace.Resources != nil {
+		fmt.Fprintf(os.Stderr, "warning: network isolation and resource limits only apply to `dem %s` sessions\n", namespace)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := ws.Setup(ctx); err != nil {
+		return nil, err
+	}
+	return ws.Activation(path), nil
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	sh, err := hook.Get(args[0])
+	if err != nil {
+		return err
+	}
+	// workspace sessions have the environment already
+	if env.Has(workspace.SessionKey) {
+		return nil
+	}
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	// stdout is evaluated by the shell, the setup progress and the output of
+	// hooks go to stderr
+	reporter, err := ext.NewReporterTo("auto", os.Stderr)
+	if err != nil {
+		return err
+	}
+	manager := workspace.New(storageDir, pluginsDir)
+	manager.Reporter = reporter
+	manager.Stdout = os.Stderr
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	link, err := manager.Find(dir)
+	if err != nil {
+		return err
+	}
+	state := hook.Decode(os.Getenv(hook.StateKey))
+	if state == nil && link == nil {
+		return nil
+	}
+	var next *hook.State
+	if link != nil {
+		next = &hook.State{
+			Namespace: link.Namespace,
+			Dir:       link.Dir,
+			Modified:  modified(manager, link.Namespace),
+			Blocked:   !manager.Allowed(link),
+		}
+		if state != nil && state.Namespace == next.Namespace && state.Dir == next.Dir && state.Modified == next.Modified && state.Blocked == next.Blocked {
+			return nil
+		}
+	}
+
+	vars := environ()
+	diff := make(hook.Diff)
+	if state != nil {
+		for key, val := range state.Revert(vars) {
+			diff[key] = val
+			if val == nil {
+				delete(vars, key)
+			} else {
+				vars[key] = *val
+			}
+		}
+		if next == nil || next.Namespace != state.Namespace {
+			fmt.Fprintf(os.Stderr, "(%s) workspace deactivated\n", state.Namespace)
+		}
+	}
+	if next == nil {
+		diff.Unset(hook.StateKey)
+		fmt.Fprint(os.Stdout, diff.Script(sh))
+		return nil
+	}
+
+	// the workspace is set up by the activation, which runs downloads and
+	// hooks, so directories linked by a marker file have to be allowed first
+	if next.Blocked {
+		fmt.Fprintf(os.Stderr, "(%s) workspace is not activated in %s, run `dem allow` to set it up and activate it\n", next.Namespace, next.Dir)
+		diff.Set(hook.StateKey, next.Encode())
+		fmt.Fprint(os.Stdout, diff.Script(sh))
+		return nil
+	}
+
+	// a failed activation is recorded as well, so that it is only retried
+	// once the directory is entered again or the configuration changed
+	activated, err := activation(manager, next.Namespace, vars["PATH"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "(%s) workspace is not activated, %v\n", next.Namespace, err)
+	} else {
+		for key, val := range hook.Activate(vars, activated, next) {
+			diff[key] = val
+		}
+		fmt.Fprintf(os.Stderr, "(%s) workspace activated in %s\n", next.Namespace, next.Dir)
+	}
+	diff.Set(hook.StateKey, next.Encode())
+	fmt.Fprint(os.Stdout, diff.Script(sh))
+	return nil
+}
+
+// NewCommand returns a new cobra.Command printing the environment changes
+// of the current directory for the shell hook
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "export <shell>",
+		Short:        "Prints the environment changes of the current directory for the shell hook",
+		Args:         cobra.ExactArgs(1),
+		Hidden:       true,
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	return cmd
+}
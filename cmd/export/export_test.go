@@ -0,0 +1,123 @@
+package export
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/hook"
+	"github.com/samuelngs/dem/pkg/workspace"
+)
+
+var statement = regexp.MustCompile(`^(export [A-Za-z_][A-Za-z0-9_]*=.*|unset [A-Za-z_][A-Za-z0-9_]*);$`)
+
+const config = `workspace:
+  shell:
+    program: /bin/sh
+  hooks:
+    post_create:
+      - echo hook output
+      - echo hook error >&2
+`
+
+// export runs the command in dir and returns its stdout
+func export(t *testing.T, dir string) string {
+	t.Helper()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = run(NewCommand(), []string{"bash"})
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// statements checks that the output only consists of shell statements
+func statements(t *testing.T, out string) {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !statement.MatchString(line) {
+			t.Errorf("stdout is not an export or unset statement: %q", line)
+		}
+	}
+}
+
+func TestStdoutOnlyStatements(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+
+	tmp, err := ioutil.TempDir("", "dem-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	storageDir := filepath.Join(tmp, "workspaces")
+	project := filepath.Join(tmp, "project")
+	os.MkdirAll(filepath.Join(storageDir, "w"), 0755)
+	os.MkdirAll(project, 0755)
+	ioutil.WriteFile(filepath.Join(storageDir, "w", ".workspace.yaml"), []byte(config), 0644)
+	ioutil.WriteFile(filepath.Join(project, ".dem"), []byte("w\n"), 0644)
+
+	settings := *globalconfig.Settings
+	defer func() { *globalconfig.Settings = settings }()
+	globalconfig.Settings.StorageDir = storageDir
+	globalconfig.Settings.PluginsDir = filepath.Join(tmp, "plugins")
+	os.Unsetenv(workspace.SessionKey)
+	defer os.Unsetenv(hook.StateKey)
+
+	// the marker file has to be allowed before the workspace is set up
+	out := export(t, project)
+	statements(t, out)
+	if strings.Contains(out, workspace.WorkspaceKey) {
+		t.Errorf("workspace is activated before it was allowed:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(storageDir, "w", ".installation")); !os.IsNotExist(err) {
+		t.Errorf("workspace is set up before it was allowed")
+	}
+	os.Setenv(hook.StateKey, state(out))
+	manager := workspace.New(storageDir, globalconfig.Settings.PluginsDir)
+	if err := manager.Allow(&workspace.Link{Namespace: "w", Dir: project, Path: filepath.Join(project, ".dem")}); err != nil {
+		t.Fatal(err)
+	}
+
+	out = export(t, project)
+	statements(t, out)
+	if !strings.Contains(out, "export "+workspace.WorkspaceKey+"='w';") {
+		t.Errorf("workspace is not activated:\n%s", out)
+	}
+
+	// deactivation
+	os.Setenv(hook.StateKey, state(out))
+	out = export(t, tmp)
+	statements(t, out)
+	if !strings.Contains(out, "unset "+hook.StateKey+";") {
+		t.Errorf("workspace is not deactivated:\n%s", out)
+	}
+}
+
+// state returns the hook state exported by the output
+func state(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "export "+hook.StateKey+"=") {
+			return strings.Trim(strings.TrimPrefix(line, "export "+hook.StateKey+"="), "';")
+		}
+	}
+	return ""
+}
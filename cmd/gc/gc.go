@@ -0,0 +1,77 @@
+// Package gc implements the `gc` command
+package gc
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/gc"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	all    bool
+	dryRun bool
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	manager := workspace.New(storageDir, pluginsDir)
+	targets := args
+	switch {
+	case all && len(args) > 0:
+		return fmt.Errorf("namespaces cannot be combined with --all")
+	case all:
+		found, err := manager.List()
+		if err != nil {
+			return err
+		}
+		targets = found
+	case len(args) == 0:
+		return cmd.Usage()
+	}
+
+	var (
+		freed  int64
+		failed bool
+	)
+	for _, namespace := range targets {
+		ws, err := manager.Get(namespace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "(%s) %v\n", namespace, err)
+			failed = true
+			continue
+		}
+		n, err := gc.Collect(os.Stdout, ws, dryRun)
+		freed += n
+		if err != nil {
+			// a workspace which cannot be collected is skipped, the others
+			// are still collected
+			fmt.Fprintf(os.Stderr, "(%s) %v\n", namespace, err)
+			failed = true
+		}
+	}
+	fmt.Println(gc.Summary(freed, dryRun))
+	if failed {
+		return fmt.Errorf("some workspaces were not collected")
+	}
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for removing unused installations
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "gc [namespace...]",
+		Short:        "Remove installations not used by workspaces",
+		Long:         "Remove toolchain installations and downloaded archives which are not referenced by the workspace configuration or lockfile",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "collect all workspaces")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print what would be removed")
+	return cmd
+}
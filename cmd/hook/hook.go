@@ -0,0 +1,49 @@
+// Package hook implements the `hook` command
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/hook"
+	"github.com/spf13/cobra"
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	sh, err := hook.Get(args[0])
+	if err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	export := []string{self}
+	if flag := cmd.Flag("config"); flag != nil && flag.Changed {
+		config, err := filepath.Abs(flag.Value.String())
+		if err != nil {
+			return err
+		}
+		export = append(export, "-c", config)
+	}
+	fmt.Print(sh.Hook(append(export, "export")))
+	return nil
+}
+
+// NewCommand returns a new cobra.Command printing the shell hook
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("hook <%s>", strings.Join(hook.Names(), "|")),
+		Short: "Prints the shell hook activating workspaces of linked directories",
+		Long: "Prints the hook for the shell, which exports the environment of the workspace linked to the current directory " +
+			"(by a .dem file naming the workspace, or as one of its projects) and reverts it once the directory is left. " +
+			"Directories linked by a .dem file are only activated once they are allowed by `dem allow`. " +
+			`Add eval "$(dem hook bash)" to ~/.bashrc, eval "$(dem hook zsh)" to ~/.zshrc or dem hook fish | source to ~/.config/fish/config.fish`,
+		Args:         cobra.ExactArgs(1),
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	return cmd
+}
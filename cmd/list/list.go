@@ -3,32 +3,23 @@ package list
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/samuelngs/dem/pkg/globalconfig"
-	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
 func run(cmd *cobra.Command, args []string) error {
 	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
 
-	files, err := ioutil.ReadDir(storageDir)
+	namespaces, err := workspace.New(storageDir, pluginsDir).List()
 	if err != nil {
 		return err
 	}
 
-	namespaces := make([]string, 0)
-	for _, file := range files {
-		workingDir := fmt.Sprintf("%s/%s", storageDir, file.Name())
-		configPath := fmt.Sprintf("%s/%s", workingDir, ".workspace.yaml")
-		if file.IsDir() && workspaceconfig.IsValid(configPath) {
-			namespaces = append(namespaces, file.Name())
-		}
-	}
-
 	fmt.Println(strings.Join(namespaces, "\n"))
 	return nil
 }
@@ -0,0 +1,59 @@
+package install
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+var index string
+
+func run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Usage()
+	}
+	location := index
+	if len(location) == 0 {
+		location = os.ExpandEnv(globalconfig.Settings.PluginIndex)
+	}
+	idx, err := registry.Fetch(location)
+	if err != nil {
+		return err
+	}
+	reg, err := registry.Load(os.ExpandEnv(globalconfig.Settings.PluginsDir))
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		// name@version installs a specific version
+		name, version := arg, ""
+		if i := strings.Index(arg, "@"); i >= 0 {
+			name, version = arg[:i], arg[i+1:]
+		}
+		release, artifact, err := idx.Find(name, version)
+		if err != nil {
+			return err
+		}
+		if err := reg.Install(idx, name, release, artifact); err != nil {
+			return err
+		}
+		fmt.Printf("extension %s %s installed\n", name, release.Version)
+	}
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for installing extensions
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "install [name[@version]...]",
+		Short:        "Install extensions from the plugin index",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&index, "index", "", "plugin index path or url (default plugin_index of the config file)")
+	return cmd
+}
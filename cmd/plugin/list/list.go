@@ -0,0 +1,83 @@
+package list
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/registry"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"github.com/spf13/cobra"
+)
+
+// usage maps the `with` keys to the workspaces using them
+func usage(storageDir, pluginsDir string) (map[string][]string, error) {
+	namespaces, err := workspaceconfig.List(storageDir)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string][]string)
+	for _, namespace := range namespaces {
+		config, err := workspaceconfig.Open(namespace, storageDir, pluginsDir)
+		if err != nil {
+			continue
+		}
+		for key := range config.Workspace.With {
+			used[key] = append(used[key], namespace)
+		}
+	}
+	return used, nil
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	reg, err := registry.Load(pluginsDir)
+	if err != nil {
+		return err
+	}
+	modules, errs, err := ext.Modules(pluginsDir)
+	if err != nil {
+		return err
+	}
+	used, err := usage(storageDir, pluginsDir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tWORKSPACES")
+	for _, m := range modules {
+		version := "-"
+		if entry, ok := reg.Plugins[m.Name]; ok {
+			version = entry.Version
+		}
+		workspaces := "-"
+		if namespaces := used[m.Key]; len(namespaces) > 0 {
+			workspaces = strings.Join(namespaces, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.Name, version, workspaces)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for listing extensions
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List installed extensions",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	return cmd
+}
@@ -0,0 +1,24 @@
+// Package plugin implements the `plugin` command
+package plugin
+
+import (
+	"github.com/samuelngs/dem/cmd/plugin/install"
+	"github.com/samuelngs/dem/cmd/plugin/list"
+	"github.com/samuelngs/dem/cmd/plugin/remove"
+	"github.com/samuelngs/dem/cmd/plugin/update"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand returns a new cobra.Command for managing extensions
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin [command]",
+		Short: "Manage installed extensions",
+		Long:  "Install, list, remove and update extensions from a plugin index",
+	}
+	cmd.AddCommand(install.NewCommand())
+	cmd.AddCommand(list.NewCommand())
+	cmd.AddCommand(remove.NewCommand())
+	cmd.AddCommand(update.NewCommand())
+	return cmd
+}
@@ -0,0 +1,38 @@
+package remove
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Usage()
+	}
+	reg, err := registry.Load(os.ExpandEnv(globalconfig.Settings.PluginsDir))
+	if err != nil {
+		return err
+	}
+	for _, name := range args {
+		if err := reg.Remove(name); err != nil {
+			return err
+		}
+		fmt.Printf("extension %s removed\n", name)
+	}
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for removing extensions
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "remove [name...]",
+		Short:        "Remove installed extensions",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	return cmd
+}
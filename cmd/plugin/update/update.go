@@ -0,0 +1,73 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/registry"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/spf13/cobra"
+)
+
+var index string
+
+func run(cmd *cobra.Command, args []string) error {
+	reg, err := registry.Load(os.ExpandEnv(globalconfig.Settings.PluginsDir))
+	if err != nil {
+		return err
+	}
+	names := args
+	if len(names) == 0 {
+		for name := range reg.Plugins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	indexes := make(map[string]*registry.Index)
+	for _, name := range names {
+		entry, ok := reg.Plugins[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "warning: extension '%s' was not installed from a plugin index, skipping\n", name)
+			continue
+		}
+		// extensions are updated from the index they were installed from
+		location := entry.Index
+		if len(index) > 0 {
+			location = index
+		}
+		idx, ok := indexes[location]
+		if !ok {
+			if idx, err = registry.Fetch(location); err != nil {
+				return err
+			}
+			indexes[location] = idx
+		}
+		release, artifact, err := idx.Find(name, "")
+		if err != nil {
+			return err
+		}
+		if resolver.Compare(release.Version, entry.Version) <= 0 {
+			fmt.Printf("extension %s %s is up to date\n", name, entry.Version)
+			continue
+		}
+		if err := reg.Install(idx, name, release, artifact); err != nil {
+			return err
+		}
+		fmt.Printf("extension %s updated %s -> %s\n", name, entry.Version, release.Version)
+	}
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for updating extensions
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "update [name...]",
+		Short:        "Update installed extensions to their newest version",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&index, "index", "", "plugin index path or url (default the index the extension was installed from)")
+	return cmd
+}
@@ -0,0 +1,94 @@
+package buildimage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/gc"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace string
+	output    string
+	tag       string
+	base      string
+	progress  string
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	reporter, err := ext.NewReporter(progress)
+	if err != nil {
+		return err
+	}
+	manager := workspace.New(storageDir, pluginsDir)
+	manager.Reporter = reporter
+	manager.Strict = true
+
+	ws, err := manager.Get(namespace)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err = ws.Setup(ctx)
+	stop()
+	if err != nil {
+		return err
+	}
+	img, err := ws.Image(base, tag)
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+
+	path := output
+	if len(path) == 0 {
+		path = fmt.Sprintf("%s.tar", namespace)
+	}
+	// the tarball is written next to the output and renamed once complete
+	f, err := ioutil.TempFile(filepath.Dir(path), ".dem-image-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := img.Write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	size, _ := gc.Size(path)
+	fmt.Printf("(%s) image written to %s (%s)\n", namespace, path, gc.HumanSize(size))
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for exporting the workspace as image
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "build-image",
+		Short:        "Export the workspace as OCI image",
+		Long:         "Export the installed toolchains, environment and shell of the workspace as OCI image layout tarball, no container runtime is required",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the image tarball (default \"<namespace>.tar\")")
+	cmd.Flags().StringVar(&tag, "tag", "latest", "tag of the image in the layout")
+	cmd.Flags().StringVar(&base, "base", "", "OCI image layout directory of the base image, required unless the shell is installed in the workspace")
+	cmd.Flags().StringVar(&progress, "progress", "auto", "setup progress output (auto, tty, plain, json)")
+	namespace = ns
+	return cmd
+}
@@ -0,0 +1,60 @@
+package devcontainer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/devcontainer"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace string
+	dir       string
+	image     string
+	force     bool
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	manager := workspace.New(storageDir, pluginsDir)
+	manager.Strict = true
+	ws, err := manager.Get(namespace)
+	if err != nil {
+		return err
+	}
+	config, skipped, err := ws.Devcontainer(image)
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+	for _, extension := range skipped {
+		fmt.Fprintf(os.Stderr, "warning: %s has no devcontainer feature and is not exported\n", extension)
+	}
+	path, err := config.Write(dir, force)
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+	fmt.Printf("(%s) devcontainer written to %s\n", namespace, path)
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for exporting the workspace as
+// devcontainer configuration
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "devcontainer",
+		Short:        "Export the workspace as devcontainer configuration",
+		Long:         "Write a .devcontainer/devcontainer.json which installs the toolchains of the workspace with devcontainer features",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&dir, "dir", "d", ".", "project directory of the configuration")
+	cmd.Flags().StringVar(&image, "image", devcontainer.DefaultImage, "base image of the devcontainer")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "overwrite an existing configuration")
+	namespace = ns
+	return cmd
+}
@@ -0,0 +1,45 @@
+package gc
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/gc"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace string
+	dryRun    bool
+)
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	ws, err := workspace.New(storageDir, pluginsDir).Get(namespace)
+	if err != nil {
+		return err
+	}
+	freed, err := gc.Collect(os.Stdout, ws, dryRun)
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+	fmt.Println(gc.Summary(freed, dryRun))
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for removing unused installations
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "gc",
+		Short:        "Remove installations not used by the workspace",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print what would be removed")
+	namespace = ns
+	return cmd
+}
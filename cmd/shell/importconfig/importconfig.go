@@ -0,0 +1,45 @@
+package importconfig
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/importer"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var namespace string
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	imported, err := importer.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+	if err := workspace.New(storageDir, pluginsDir).Import(namespace, imported); err != nil {
+		return err
+	}
+	for _, s := range imported.Unmapped {
+		fmt.Fprintf(os.Stderr, "warning: not imported: %s\n", s)
+	}
+	fmt.Printf("(%s) imported %s\n", namespace, args[0])
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for importing toolchain definitions
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "import-config <file>",
+		Short:        "Import a .tool-versions, devcontainer.json or mise.toml file",
+		Long:         "Translate the toolchains, environment variables and post-create commands of a .tool-versions, devcontainer.json or mise.toml file into the workspace configuration. Definitions without equivalent are reported, comments of the configuration are not kept.",
+		Args:         cobra.ExactArgs(1),
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	namespace = ns
+	return cmd
+}
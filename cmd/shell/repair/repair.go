@@ -0,0 +1,138 @@
+package repair
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace string
+	progress  string
+	dryRun    bool
+)
+
+// selected reports whether the extension is one of the requested ones, all
+// extensions are selected if none is requested. The names it provides are
+// removed from unmatched.
+func selected(extension ext.Extension, names, unmatched map[string]bool) bool {
+	if len(names) == 0 {
+		return true
+	}
+	found := false
+	for _, name := range ext.Provides(extension) {
+		if names[name] {
+			delete(unmatched, name)
+			found = true
+		}
+	}
+	return found
+}
+
+// verify prints the problems of the selected installations and returns them
+// by extension
+func verify(exts []ext.Extension, names map[string]bool) (map[*ext.Installation][]ext.Problem, error) {
+	broken := make(map[*ext.Installation][]ext.Problem)
+	unmatched := make(map[string]bool)
+	for name := range names {
+		unmatched[name] = true
+	}
+	for _, extension := range exts {
+		if !selected(extension, names, unmatched) {
+			continue
+		}
+		installer, ok := extension.(ext.Installer)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "warning: %s does not record its installation, skipped\n", extension)
+			continue
+		}
+		installation := installer.Installation()
+		problems, err := installation.Verify()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", extension, err)
+		}
+		for _, problem := range problems {
+			fmt.Printf("(%s) %s: %s\n", namespace, extension, problem)
+		}
+		if len(problems) > 0 {
+			broken[installation] = problems
+		}
+	}
+	for name := range unmatched {
+		return nil, fmt.Errorf("extension '%s' is not enabled in workspace '%s'", name, namespace)
+	}
+	return broken, nil
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	reporter, err := ext.NewReporter(progress)
+	if err != nil {
+		return err
+	}
+	manager := workspace.New(storageDir, pluginsDir)
+	manager.Reporter = reporter
+
+	ws, err := manager.Get(namespace)
+	if err != nil {
+		return err
+	}
+	ws.Diagnostics.Print(os.Stderr)
+	names := make(map[string]bool)
+	for _, name := range args {
+		names[name] = true
+	}
+	broken, err := verify(ws.Extensions, names)
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+	if len(broken) == 0 {
+		fmt.Printf("(%s) all installations are intact\n", namespace)
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+
+	// forgotten installations are set up again
+	for installation, problems := range broken {
+		paths := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			if len(problem.Path) == 0 {
+				paths = nil
+				break
+			}
+			paths = append(paths, problem.Path)
+		}
+		if err := installation.Forget(paths...); err != nil {
+			return fmt.Errorf("(%s) %v", namespace, err)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return ws.Setup(ctx)
+}
+
+// NewCommand returns a new cobra.Command for repairing workspace installations
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "repair [extension...]",
+		Short:        "Reinstall incomplete or corrupted installations",
+		Long:         "Verify the installations of the workspace against their install records and reinstall the incomplete or corrupted ones",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&progress, "progress", "auto", "setup progress output (auto, tty, plain, json)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the problems found")
+	namespace = ns
+	return cmd
+}
@@ -0,0 +1,58 @@
+package repair
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// fake is an extension which was never installed
+type fake struct {
+	name         string
+	installation *ext.Installation
+}
+
+func (v *fake) Init(*workspaceconfig.Config) (bool, error) { return true, nil }
+func (v *fake) SetupTasks() ext.SetupTasks                 { return nil }
+func (v *fake) Environment() map[string]string             { return nil }
+func (v *fake) Aliases() map[string]string                 { return nil }
+func (v *fake) Sources() []string                          { return nil }
+func (v *fake) Paths() []string                            { return nil }
+func (v *fake) String() string                             { return v.name }
+func (v *fake) Requires() []string                         { return nil }
+func (v *fake) Provides() []string                         { return []string{v.name} }
+func (v *fake) Installation() *ext.Installation            { return v.installation }
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	exts := make([]ext.Extension, 0)
+	for _, name := range []string{"go", "node", "python"} {
+		exts = append(exts, &fake{name, &ext.Installation{Record: filepath.Join(dir, name+".yaml"), Version: "1.0"}})
+	}
+	tests := []struct {
+		names  []string
+		broken int
+		err    bool
+	}{
+		{nil, 3, false},
+		{[]string{"go"}, 1, false},
+		{[]string{"go", "python"}, 2, false},
+		{[]string{"java"}, 0, true},
+	}
+	for _, test := range tests {
+		names := make(map[string]bool)
+		for _, name := range test.names {
+			names[name] = true
+		}
+		broken, err := verify(exts, names)
+		if (err != nil) != test.err {
+			t.Errorf("verify(%v) err = %v", test.names, err)
+			continue
+		}
+		if len(broken) != test.broken {
+			t.Errorf("verify(%v) = %d broken installations, want %d", test.names, len(broken), test.broken)
+		}
+	}
+}
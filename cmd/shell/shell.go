@@ -2,142 +2,69 @@
 package shell
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
-	"plugin"
-	"strings"
+	"os/signal"
+	"syscall"
 
+	"github.com/samuelngs/dem/cmd/shell/buildimage"
+	"github.com/samuelngs/dem/cmd/shell/devcontainer"
 	"github.com/samuelngs/dem/cmd/shell/edit"
+	"github.com/samuelngs/dem/cmd/shell/gc"
+	"github.com/samuelngs/dem/cmd/shell/importconfig"
+	"github.com/samuelngs/dem/cmd/shell/repair"
+	"github.com/samuelngs/dem/cmd/shell/stats"
+	"github.com/samuelngs/dem/cmd/shell/upgrade"
 	"github.com/samuelngs/dem/pkg/ext"
 	"github.com/samuelngs/dem/pkg/globalconfig"
-	"github.com/samuelngs/dem/pkg/shell"
 	"github.com/samuelngs/dem/pkg/util/env"
-	"github.com/samuelngs/dem/pkg/util/envcomposer"
-	"github.com/samuelngs/dem/pkg/util/fs"
-	"github.com/samuelngs/dem/pkg/util/homedir"
-	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"github.com/samuelngs/dem/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
-var key = "CWKS"
-
-func extensions(config *workspaceconfig.Config) []ext.Extension {
-	extensions := make([]ext.Extension, 0)
-	if config.Workspace.With != nil {
-		modules, err := filepath.Glob(fmt.Sprintf("%s/*.so", config.PluginsDir))
-		if err != nil {
-			return nil
-		}
-		for _, module := range modules {
-			p, err := plugin.Open(module)
-			if err != nil {
-				continue
-			}
-			v, err := p.Lookup("Export")
-			if err != nil {
-				continue
-			}
-			i, ok := v.(*ext.Extension)
-			if !ok {
-				continue
-			}
-			m := *i
-			success, err := m.Init(config)
-			if !success || err != nil {
-				continue
-			}
-			extensions = append(extensions, m)
-		}
-	}
-	return extensions
-}
+var (
+	progress string
+	strict   bool
+)
 
 func createSession(namespace string) error {
 	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
 	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
-	workingDir := fmt.Sprintf("%s/%s", storageDir, namespace)
 
-	if !fs.Exists(workingDir) {
-		return fmt.Errorf("workspace '%s' does not exist", namespace)
-	}
-
-	configPath := fmt.Sprintf("%s/%s", workingDir, ".workspace.yaml")
-
-	yaml, err := workspaceconfig.Read(configPath)
+	reporter, err := ext.NewReporter(progress)
 	if err != nil {
-		return fmt.Errorf("(%s) unable to read YAML configuration", namespace)
+		return err
 	}
+	manager := workspace.New(storageDir, pluginsDir)
+	manager.Reporter = reporter
+	manager.Strict = strict
 
-	config, err := workspaceconfig.Parse(yaml)
+	ws, err := manager.Get(namespace)
 	if err != nil {
-		return fmt.Errorf("(%s) unable to parse YAML configuration", namespace)
+		return err
 	}
-	config.Namespace = namespace
-	config.WorkingDir = workingDir
-	config.PluginsDir = pluginsDir
-	config.InstallationDir = filepath.Join(workingDir, ".installation")
-	config.Src = yaml
-
-	// load workspace extensions
-	exts := extensions(config)
-
-	// environment composer
-	envcomposer := envcomposer.New()
+	ws.Diagnostics.Print(os.Stderr)
 
-	// fixes issue where backspace behaves strangely with zsh
-	envcomposer.Set("TERM", env.GetEnvAsString("TERM", "xterm"))
-	envcomposer.Set("SHELL", config.Workspace.Shell.Program)
-	// maps virtual user to shell
-	envcomposer.Set("USER", namespace)
-	envcomposer.Set("HOME", workingDir)
-	envcomposer.Set("UNMASK_HOME", homedir.Dir())
-	envcomposer.Set("PS1", fmt.Sprintf("(%s) $ ", namespace))
-	envcomposer.Set(key, "1")
-	// attempts to fix terminal copy and paste issue, it also
-	// fixes X11 compatibility issue.
-	envcomposer.Set("DISPLAY", env.GetEnvAsString("DISPLAY", ":0.0"))
-
-	for key, val := range config.Workspace.Environment {
-		envcomposer.Set(key, val)
-	}
-
-	// prepare extensions environment variables and bin paths
-	var (
-		paths   = make([]string, 0)
-		aliases = config.Workspace.Aliases
-	)
-	for _, ext := range exts {
-		for key, val := range ext.Environment() {
-			envcomposer.Set(key, val)
-		}
-		for alias, cmd := range ext.Aliases() {
-			aliases[alias] = cmd
-		}
-		paths = append(paths, ext.Paths()...)
+	// interrupting the setup cancels downloads and extraction, the shell
+	// handles interrupts itself once it started
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err = ws.Setup(ctx)
+	stop()
+	if err != nil {
+		return err
 	}
-	envcomposer.Set("EXT_PATH", strings.Join(paths, ":"))
-
-	cmd := shell.New(config.Workspace.Shell.Program, config.Workspace.Shell.Args...)
-
-	cmd.SetDir(workingDir)
-	cmd.SetEnv(envcomposer.AsMap())
-	cmd.SetAliases(aliases)
-
-	ext.Setup(exts...)
-
-	return cmd.Run()
+	return ws.Exec(context.Background(), ws.Shell())
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	if isInstance := env.Has(key); isInstance {
+	if isInstance := env.Has(workspace.SessionKey); isInstance {
 		return nil
 	}
 	if len(args) > 0 {
 		return cmd.Usage()
 	}
-	createSession(cmd.CalledAs())
-	return nil
+	return createSession(cmd.CalledAs())
 }
 
 // NewCommand returns a new cobra.Command for cluster creation
@@ -148,9 +75,19 @@ func NewCommand(namespace string) *cobra.Command {
 		Long:                  "Built-in magic commands",
 		DisableFlagsInUseLine: true,
 		SilenceErrors:         true,
+		SilenceUsage:          true,
 		Hidden:                true,
 		RunE:                  run,
 	}
+	cmd.Flags().StringVar(&progress, "progress", "auto", "Setup progress output (auto, tty, plain, json)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Abort if an extension fails to load")
+	cmd.AddCommand(buildimage.NewCommand(namespace))
+	cmd.AddCommand(devcontainer.NewCommand(namespace))
 	cmd.AddCommand(edit.NewCommand(namespace))
+	cmd.AddCommand(gc.NewCommand(namespace))
+	cmd.AddCommand(importconfig.NewCommand(namespace))
+	cmd.AddCommand(repair.NewCommand(namespace))
+	cmd.AddCommand(stats.NewCommand(namespace))
+	cmd.AddCommand(upgrade.NewCommand(namespace))
 	return cmd
 }
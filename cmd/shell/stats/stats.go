@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samuelngs/dem/pkg/gc"
+	"github.com/samuelngs/dem/pkg/util/cgroup"
+	"github.com/spf13/cobra"
+)
+
+var namespace string
+
+func run(cmd *cobra.Command, args []string) error {
+	cg, err := cgroup.Open(namespace)
+	if err != nil {
+		return err
+	}
+	stats, err := cg.Stats()
+	if err != nil {
+		return fmt.Errorf("workspace '%s' has no active resource limits", namespace)
+	}
+	cpu := time.Duration(stats.CPUUsage) * time.Microsecond
+
+	fmt.Printf("cgroup   %s\n", cg.Path())
+	fmt.Printf("procs    %d\n", stats.Procs)
+	fmt.Printf("memory   %s / %s\n", gc.HumanSize(int64(stats.MemoryCurrent)), stats.MemoryMax)
+	fmt.Printf("cpu      %s (weight %s, max %s)\n", cpu, stats.CPUWeight, stats.CPUMax)
+	fmt.Printf("pids     %d / %s\n", stats.PidsCurrent, stats.PidsMax)
+	return nil
+}
+
+// NewCommand returns a new cobra.Command for workspace resource usage
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "stats",
+		Short:                 "Show resource usage of the workspace",
+		DisableFlagsInUseLine: true,
+		RunE:                  run,
+	}
+	namespace = ns
+	return cmd
+}
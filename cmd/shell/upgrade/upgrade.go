@@ -0,0 +1,189 @@
+package upgrade
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"github.com/spf13/cobra"
+)
+
+var (
+	namespace string
+	policy    string
+	progress  string
+	dryRun    bool
+)
+
+// selected reports whether the extension is one of the requested ones, all
+// extensions are selected if none is requested. The names it provides are
+// removed from unmatched.
+func selected(extension ext.Extension, names, unmatched map[string]bool) bool {
+	if len(names) == 0 {
+		return true
+	}
+	found := false
+	for _, name := range ext.Provides(extension) {
+		if names[name] {
+			delete(unmatched, name)
+			found = true
+		}
+	}
+	return found
+}
+
+// skipped is a selected extension which is not upgraded
+type skipped struct {
+	extension ext.Extension
+	reason    string
+}
+
+// check looks up the available upgrades of the selected extensions, the
+// extensions which cannot be upgraded are returned as skipped
+func check(exts []ext.Extension, names map[string]bool, p resolver.Policy) ([]*resolver.Upgrade, []*skipped, error) {
+	upgrades := make([]*resolver.Upgrade, 0)
+	skips := make([]*skipped, 0)
+	unmatched := make(map[string]bool)
+	for name := range names {
+		unmatched[name] = true
+	}
+	for _, extension := range exts {
+		if !selected(extension, names, unmatched) {
+			continue
+		}
+		upgrader, ok := extension.(ext.Upgrader)
+		if !ok {
+			skips = append(skips, &skipped{extension, "upgrades are not supported"})
+			continue
+		}
+		upgrade, err := upgrader.Upgrade(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", extension, err)
+		}
+		if upgrade == nil {
+			skips = append(skips, &skipped{extension, "detected and multiple versions are not upgraded"})
+			continue
+		}
+		upgrades = append(upgrades, upgrade)
+	}
+	for name := range unmatched {
+		return nil, nil, fmt.Errorf("extension '%s' is not enabled in workspace '%s'", name, namespace)
+	}
+	return upgrades, skips, nil
+}
+
+// apply rewrites the pinned versions in the workspace configuration and the
+// upgraded ranges in the lockfile
+func apply(config *workspaceconfig.Config, upgrades []*resolver.Upgrade) error {
+	src := config.Src
+	for _, upgrade := range upgrades {
+		if !upgrade.Available() {
+			continue
+		}
+		if !upgrade.Pinned() {
+			if err := resolver.Lock(config, upgrade); err != nil {
+				return err
+			}
+			continue
+		}
+		dat, err := workspaceconfig.Set(src, upgrade.Latest, "workspace", "with", upgrade.Name, "version")
+		if err != nil {
+			return err
+		}
+		src = dat
+	}
+	if bytes.Equal(src, config.Src) {
+		return nil
+	}
+	if _, err := workspaceconfig.Parse(src); err != nil {
+		return fmt.Errorf("unable to rewrite YAML configuration, %v", err)
+	}
+	return fs.WriteFile(config.ConfigPath, src)
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	storageDir := os.ExpandEnv(globalconfig.Settings.StorageDir)
+	pluginsDir := os.ExpandEnv(globalconfig.Settings.PluginsDir)
+
+	p, err := resolver.ParsePolicy(policy)
+	if err != nil {
+		return err
+	}
+	reporter, err := ext.NewReporter(progress)
+	if err != nil {
+		return err
+	}
+	manager := workspace.New(storageDir, pluginsDir)
+	manager.Reporter = reporter
+
+	ws, err := manager.Get(namespace)
+	if err != nil {
+		return err
+	}
+	ws.Diagnostics.Print(os.Stderr)
+	names := make(map[string]bool)
+	for _, name := range args {
+		names[name] = true
+	}
+	upgrades, skips, err := check(ws.Extensions, names, p)
+	if err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+
+	var available int
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, upgrade := range upgrades {
+		if upgrade.Available() {
+			available++
+			fmt.Fprintf(w, "%s\t%s\t->\t%s\n", upgrade.Name, upgrade.Current, upgrade.Latest)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t\tup to date\n", upgrade.Name, upgrade.Current)
+		}
+	}
+	for _, skip := range skips {
+		fmt.Fprintf(w, "%s\t\t\tskipped, %s\n", skip.extension, skip.reason)
+	}
+	w.Flush()
+	if dryRun || available == 0 {
+		return nil
+	}
+
+	if err := apply(ws.Config, upgrades); err != nil {
+		return fmt.Errorf("(%s) %v", namespace, err)
+	}
+
+	// set up the upgraded versions with the rewritten configuration
+	ws, err = manager.Get(namespace)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return ws.Setup(ctx)
+}
+
+// NewCommand returns a new cobra.Command for upgrading workspace toolchains
+func NewCommand(ns string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "upgrade [extension...]",
+		Short:        "Upgrade the toolchains of the workspace",
+		Long:         "Upgrade the toolchains of the workspace to the newest versions allowed by the policy",
+		RunE:         run,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&policy, "policy", string(resolver.Minor), "how far versions are upgraded (patch, minor, major)")
+	cmd.Flags().StringVar(&progress, "progress", "auto", "setup progress output (auto, tty, plain, json)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the available upgrades")
+	namespace = ns
+	return cmd
+}
@@ -0,0 +1,70 @@
+package upgrade
+
+import (
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+type fake struct {
+	name    string
+	upgrade *resolver.Upgrade
+}
+
+func (v *fake) Init(*workspaceconfig.Config) (bool, error) { return true, nil }
+func (v *fake) SetupTasks() ext.SetupTasks                 { return nil }
+func (v *fake) Environment() map[string]string             { return nil }
+func (v *fake) Aliases() map[string]string                 { return nil }
+func (v *fake) Sources() []string                          { return nil }
+func (v *fake) Paths() []string                            { return nil }
+func (v *fake) String() string                             { return v.name }
+func (v *fake) Requires() []string                         { return nil }
+func (v *fake) Provides() []string                         { return []string{v.name} }
+
+type upgrader struct{ *fake }
+
+func (v *upgrader) Upgrade(resolver.Policy) (*resolver.Upgrade, error) { return v.upgrade, nil }
+
+func TestCheck(t *testing.T) {
+	exts := []ext.Extension{
+		&upgrader{&fake{name: "go", upgrade: &resolver.Upgrade{Name: "go", Current: "1.20", Latest: "1.21.3"}}},
+		&upgrader{&fake{name: "node"}},
+		&fake{name: "rust"},
+	}
+	tests := []struct {
+		names    []string
+		upgrades int
+		skipped  []string
+		err      bool
+	}{
+		{nil, 1, []string{"node", "rust"}, false},
+		{[]string{"go"}, 1, nil, false},
+		{[]string{"rust"}, 0, []string{"rust"}, false},
+		{[]string{"java"}, 0, nil, true},
+	}
+	for _, test := range tests {
+		names := make(map[string]bool)
+		for _, name := range test.names {
+			names[name] = true
+		}
+		upgrades, skips, err := check(exts, names, resolver.Minor)
+		if (err != nil) != test.err {
+			t.Errorf("check(%v) err = %v", test.names, err)
+			continue
+		}
+		if len(upgrades) != test.upgrades {
+			t.Errorf("check(%v) = %d upgrades, want %d", test.names, len(upgrades), test.upgrades)
+		}
+		if len(skips) != len(test.skipped) {
+			t.Errorf("check(%v) skipped %d extensions, want %v", test.names, len(skips), test.skipped)
+			continue
+		}
+		for i, skip := range skips {
+			if skip.extension.String() != test.skipped[i] {
+				t.Errorf("check(%v) skipped %s, want %s", test.names, skip.extension, test.skipped[i])
+			}
+		}
+	}
+}
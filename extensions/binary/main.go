@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/mholt/archiver"
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/util/downloader"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// Example of .workspace.yaml:
+//
+// workspace:
+//   shell:
+//     program: /bin/zsh
+//   with:
+//     binaries:
+//       - name: kubectl
+//         version: 1.28.4
+//         url_template: https://dl.k8s.io/release/v{{.Version}}/bin/{{.OS}}/{{.Arch}}/kubectl
+//       - name: terraform
+//         version: 1.6.5
+//         url_template: https://releases.hashicorp.com/terraform/{{.Version}}/terraform_{{.Version}}_{{.OS}}_{{.Arch}}.zip
+//         checksum: sha256:<sha256 of the zip archive>
+//         archive_path: terraform
+
+type plugin struct {
+	wsconf   *workspaceconfig.Config
+	binaries []*binary
+}
+
+type config struct {
+	Workspace *workspaceConfig `yaml:"workspace"`
+}
+
+type workspaceConfig struct {
+	With *withConfig `yaml:"with"`
+}
+
+type withConfig struct {
+	Binaries []*binaryConfig `yaml:"binaries"`
+}
+
+type binaryConfig struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	URLTemplate string `yaml:"url_template"`
+	Checksum    string `yaml:"checksum"`
+	ArchivePath string `yaml:"archive_path"`
+}
+
+type binary struct {
+	*binaryConfig
+	installURL   string
+	installPath  string
+	releasesPath string
+	downloadPath string
+	binPath      string
+}
+
+type templateData struct {
+	Name    string
+	Version string
+	OS      string
+	Arch    string
+}
+
+func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
+	var binconf *config
+	if err := yaml.Unmarshal(wsconf.Src, &binconf); err != nil {
+		return false, err
+	}
+	if binconf == nil || binconf.Workspace == nil || binconf.Workspace.With == nil || len(binconf.Workspace.With.Binaries) == 0 {
+		return false, nil
+	}
+	v.wsconf = wsconf
+	v.binaries = make([]*binary, 0)
+	for _, conf := range binconf.Workspace.With.Binaries {
+		if len(conf.Name) == 0 || len(conf.Version) == 0 || len(conf.URLTemplate) == 0 {
+			return false, fmt.Errorf("binary requires name, version and url_template")
+		}
+		tmpl, err := template.New(conf.Name).Parse(conf.URLTemplate)
+		if err != nil {
+			return false, fmt.Errorf("binary %s: %v", conf.Name, err)
+		}
+		var b bytes.Buffer
+		data := &templateData{
+			Name:    conf.Name,
+			Version: conf.Version,
+			OS:      runtime.GOOS,
+			Arch:    runtime.GOARCH,
+		}
+		if err := tmpl.Execute(&b, data); err != nil {
+			return false, fmt.Errorf("binary %s: %v", conf.Name, err)
+		}
+		u, err := url.Parse(b.String())
+		if err != nil {
+			return false, fmt.Errorf("binary %s: %v", conf.Name, err)
+		}
+		bin := &binary{
+			binaryConfig: conf,
+			installURL:   u.String(),
+			installPath:  filepath.Join(wsconf.InstallationDir, "binaries", conf.Name, conf.Version),
+			releasesPath: filepath.Join(wsconf.InstallationDir, "binaries", "releases"),
+		}
+		bin.downloadPath = filepath.Join(bin.releasesPath, fmt.Sprintf("%s-%s-%s", conf.Name, conf.Version, path.Base(u.Path)))
+		bin.binPath = filepath.Join(bin.installPath, "bin", conf.Name)
+		v.binaries = append(v.binaries, bin)
+	}
+	return true, nil
+}
+
+// verify compares the sha256 checksum of the downloaded file
+func (v *binary) verify() error {
+	expected := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(v.Checksum), "sha256:"))
+	if len(expected) == 0 {
+		return nil
+	}
+	f, err := os.Open(v.downloadPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != expected {
+		os.Remove(v.downloadPath)
+		return fmt.Errorf("checksum mismatch, expected %s but got %s", expected, actual)
+	}
+	return nil
+}
+
+// install extracts the binary if the download is an archive, otherwise the
+// download itself is the binary. The binary is written next to its path and
+// renamed once complete.
+func (v *binary) install(ctx context.Context) error {
+	if err := fs.Mkdir(filepath.Dir(v.binPath)); err != nil {
+		return err
+	}
+	tmpPath := v.binPath + ".tmp"
+	defer os.Remove(tmpPath)
+	src := v.downloadPath
+	if format, err := archiver.ByExtension(v.downloadPath); err == nil {
+		unarchiver, ok := format.(archiver.Unarchiver)
+		if !ok {
+			// single compressed file (e.g jq.gz)
+			if err := archiver.DecompressFile(v.downloadPath, tmpPath); err != nil {
+				return err
+			}
+			if err := os.Chmod(tmpPath, 0755); err != nil {
+				return err
+			}
+			return os.Rename(tmpPath, v.binPath)
+		}
+		extractPath := filepath.Join(v.installPath, "archive")
+		os.RemoveAll(extractPath)
+		defer os.RemoveAll(extractPath)
+		if err := unpack.Unarchive(ctx, unarchiver, v.downloadPath, extractPath); err != nil {
+			return err
+		}
+		archivePath := v.ArchivePath
+		if len(archivePath) == 0 {
+			archivePath = v.Name
+		}
+		src = filepath.Join(extractPath, archivePath)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, v.binPath)
+}
+
+func (v *plugin) SetupTasks() ext.SetupTasks {
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	for _, bin := range v.binaries {
+		status, err := installation.Check(bin.binPath, bin.binPath)
+		if err != nil {
+			return ext.SetupTasks{ext.Failure(fmt.Sprintf("checking %s", bin.Name), err)}
+		}
+		if status == ext.Installed {
+			continue
+		}
+		bin := bin
+		if status == ext.Adoptable {
+			tasks = append(tasks, ext.Adopt(fmt.Sprintf("recording %s", bin.Name), bin.binPath))
+			continue
+		}
+		tasks = append(tasks,
+			ext.Procedure(fmt.Sprintf("downloading %s", bin.Name), func(ctx context.Context, bar ext.ProgressBar) error {
+				if err := fs.Mkdir(bin.installPath, bin.releasesPath); err != nil {
+					return err
+				}
+				cb := make(chan int)
+				go func() {
+					var lp int
+					for progress := range cb {
+						bar.IncrBy(progress - lp)
+						lp = progress
+					}
+				}()
+				if err := downloader.New(bin.installURL, bin.downloadPath).Start(ctx, cb); err != nil {
+					return err
+				}
+				return bin.verify()
+			}),
+			ext.Procedure(fmt.Sprintf("installing %s", bin.Name), func(ctx context.Context, bar ext.ProgressBar) error {
+				return bin.install(ctx)
+			}, ext.Installs(bin.binPath)),
+		)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks
+}
+
+func (v *plugin) Environment() map[string]string {
+	return nil
+}
+
+func (v *plugin) Aliases() map[string]string {
+	return nil
+}
+
+func (v *plugin) Sources() []string {
+	return nil
+}
+
+func (v *plugin) Paths() []string {
+	paths := make([]string, len(v.binaries))
+	for i, bin := range v.binaries {
+		paths[i] = filepath.Dir(bin.binPath)
+	}
+	return paths
+}
+
+func (v *plugin) Owns() []string {
+	paths := make([]string, 0)
+	for _, bin := range v.binaries {
+		paths = append(paths, bin.installPath, bin.downloadPath)
+	}
+	return paths
+}
+
+func (v *plugin) Installation() *ext.Installation {
+	installation := &ext.Installation{Record: ext.StatePath(v.wsconf, "binaries")}
+	versions := make([]string, len(v.binaries))
+	for i, bin := range v.binaries {
+		versions[i] = fmt.Sprintf("%s %s", bin.Name, bin.Version)
+		installation.Paths = append(installation.Paths, bin.binPath)
+	}
+	installation.Version = strings.Join(versions, ", ")
+	return installation
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	provides := make([]string, len(v.binaries))
+	for i, bin := range v.binaries {
+		provides[i] = bin.Name
+	}
+	return provides
+}
+
+func (v *plugin) String() string {
+	return "binaries"
+}
+
+// Export is a plugin instance used for workspace
+var Export = ext.Extension(new(plugin))
+
+// Key is the key of the extension in the `with` section
+var Key = "binaries"
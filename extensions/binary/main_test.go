@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name string
+		with string
+		ok   bool
+		urls []string
+		err  string
+	}{
+		{"not configured", "    go:\n      version: 1.21.3\n", false, nil, ""},
+		{
+			"templates",
+			"    binaries:\n" +
+				"      - {name: kubectl, version: 1.28.4, url_template: \"https://dl.k8s.io/release/v{{.Version}}/bin/{{.OS}}/{{.Arch}}/{{.Name}}\"}\n" +
+				"      - {name: jq, version: \"1.7\", url_template: \"https://example.com/jq-{{.Version}}.gz\"}\n",
+			true,
+			[]string{
+				fmt.Sprintf("https://dl.k8s.io/release/v1.28.4/bin/%s/%s/kubectl", runtime.GOOS, runtime.GOARCH),
+				"https://example.com/jq-1.7.gz",
+			},
+			"",
+		},
+		{"missing url", "    binaries:\n      - {name: jq, version: \"1.7\"}\n", false, nil, "requires name, version and url_template"},
+		{"invalid template", "    binaries:\n      - {name: jq, version: \"1.7\", url_template: \"{{.Version\"}\n", false, nil, "binary jq"},
+		{"unknown field", "    binaries:\n      - {name: jq, version: \"1.7\", url_template: \"{{.Release}}\"}\n", false, nil, "binary jq"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			v := new(plugin)
+			ok, err := v.Init(&workspaceconfig.Config{
+				InstallationDir: dir,
+				Src:             []byte("workspace:\n  with:\n" + test.with),
+			})
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Init err = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != test.ok {
+				t.Fatalf("Init = %v, want %v", ok, test.ok)
+			}
+			if len(v.binaries) != len(test.urls) {
+				t.Fatalf("binaries = %d, want %d", len(v.binaries), len(test.urls))
+			}
+			for i, bin := range v.binaries {
+				if bin.installURL != test.urls[i] {
+					t.Errorf("%s URL = %s, want %s", bin.Name, bin.installURL, test.urls[i])
+				}
+				if want := filepath.Join(dir, "binaries", bin.Name, bin.Version, "bin", bin.Name); bin.binPath != want {
+					t.Errorf("%s path = %s, want %s", bin.Name, bin.binPath, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte("#!/bin/sh\n")
+	checksum := fmt.Sprintf("%x", sha256.Sum256(body))
+	tests := []struct {
+		name     string
+		checksum string
+		err      bool
+	}{
+		{"no checksum", "", false},
+		{"match", "sha256:" + strings.ToUpper(checksum), false},
+		{"bare", checksum, false},
+		{"mismatch", strings.Repeat("0", 64), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bin := &binary{
+				binaryConfig: &binaryConfig{Name: "tool", Checksum: test.checksum},
+				downloadPath: filepath.Join(t.TempDir(), "tool"),
+			}
+			ioutil.WriteFile(bin.downloadPath, body, 0644)
+			if err := bin.verify(); (err != nil) != test.err {
+				t.Fatalf("verify err = %v, want error %v", err, test.err)
+			}
+			// a corrupted download is downloaded again
+			if _, err := os.Stat(bin.downloadPath); os.IsNotExist(err) != test.err {
+				t.Errorf("download removed = %v, want %v", os.IsNotExist(err), test.err)
+			}
+		})
+	}
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for name, body := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(body))})
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gw.Close()
+}
+
+func writeGz(t *testing.T, path string, body string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	gw.Write([]byte(body))
+	gw.Close()
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		name        string
+		download    string
+		archivePath string
+		write       func(*testing.T, string)
+	}{
+		{"binary", "tool", "", func(t *testing.T, path string) {
+			ioutil.WriteFile(path, []byte("tool"), 0644)
+		}},
+		{"compressed", "tool.gz", "", func(t *testing.T, path string) {
+			writeGz(t, path, "tool")
+		}},
+		{"archive", "tool.tar.gz", "", func(t *testing.T, path string) {
+			writeTarGz(t, path, map[string]string{"tool": "tool", "README": "readme"})
+		}},
+		{"archive path", "tool.tar.gz", "dist/bin/tool", func(t *testing.T, path string) {
+			writeTarGz(t, path, map[string]string{"dist/bin/tool": "tool", "dist/README": "readme"})
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			bin := &binary{
+				binaryConfig: &binaryConfig{Name: "tool", ArchivePath: test.archivePath},
+				installPath:  filepath.Join(dir, "tool", "1.0"),
+				downloadPath: filepath.Join(dir, "releases", test.download),
+			}
+			bin.binPath = filepath.Join(bin.installPath, "bin", "tool")
+			os.MkdirAll(filepath.Dir(bin.downloadPath), 0755)
+			test.write(t, bin.downloadPath)
+			if err := bin.install(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(bin.binPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0111 == 0 {
+				t.Errorf("binary is not executable, %s", info.Mode())
+			}
+			if b, _ := ioutil.ReadFile(bin.binPath); string(b) != "tool" {
+				t.Errorf("binary = %q, want %q", b, "tool")
+			}
+			// only the binary is kept
+			files, _ := ioutil.ReadDir(bin.installPath)
+			if len(files) != 1 || files[0].Name() != "bin" {
+				t.Errorf("install path contains %v", files)
+			}
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/mholt/archiver"
+	"github.com/samuelngs/dem/pkg/detect"
 	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/shim"
 	"github.com/samuelngs/dem/pkg/util/downloader"
 	"github.com/samuelngs/dem/pkg/util/envcomposer"
 	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
 	"github.com/samuelngs/dem/pkg/workspaceconfig"
 	"gopkg.in/yaml.v2"
 )
@@ -21,18 +27,36 @@ import (
 //     program: /bin/zsh
 //   with:
 //     go:
-//       version: 1.11.2
+//       version: 1.11.2 # or 1.11.x, ^1, latest, stable, auto
 //       go_path: false
 //       go_111_module: auto
+//
+// Multiple versions are switched per directory by go.mod or .go-version:
+//
+//   with:
+//     go:
+//       versions: [1.20.5, 1.21.3]
+//       default: 1.21.3
 
 var goBinaryHost = "https://dl.google.com/go"
 
 type plugin struct {
 	wsconf       *workspaceconfig.Config
 	goconf       *goConfig
+	detected     *detect.Result
+	constraint   string
+	versions     []string
+	version      string
+	resolved     bool
+	releases     []*release
+	releasesPath string
+	shim         *shim.Shim
+}
+
+type release struct {
+	version      string
 	binPath      string
 	tarName      string
-	releasesPath string
 	installURL   string
 	installPath  string
 	downloadPath string
@@ -51,9 +75,11 @@ type withConfig struct {
 }
 
 type goConfig struct {
-	Version     string `yaml:"version"`
-	GoPath      string `yaml:"go_path"`
-	Go111Module string `yaml:"go_111_module"`
+	Version     string   `yaml:"version"`
+	Versions    []string `yaml:"versions"`
+	Default     string   `yaml:"default"`
+	GoPath      string   `yaml:"go_path"`
+	Go111Module string   `yaml:"go_111_module"`
 }
 
 func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
@@ -61,46 +87,170 @@ func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
 	if err := yaml.Unmarshal(wsconf.Src, &goconf); err != nil {
 		return false, err
 	}
-	if goconf == nil || goconf.Workspace.With.Go == nil || len(goconf.Workspace.With.Go.Version) == 0 {
+	if goconf == nil || goconf.Workspace.With.Go == nil {
+		return false, nil
+	}
+	if len(goconf.Workspace.With.Go.Version) == 0 && len(goconf.Workspace.With.Go.Versions) == 0 {
 		return false, nil
 	}
 	v.wsconf = wsconf
 	v.goconf = goconf.Workspace.With.Go
-	v.tarName = fmt.Sprintf("go%s.%s-%s.tar.gz", v.goconf.Version, runtime.GOOS, runtime.GOARCH)
-	v.installURL = fmt.Sprintf("%s/%s", goBinaryHost, v.tarName)
-	v.installPath = filepath.Join(v.wsconf.InstallationDir, "go", v.goconf.Version)
+	if v.goconf.Version == detect.Auto {
+		detected, err := detect.Go(wsconf.WorkingDir)
+		if err != nil {
+			return false, err
+		}
+		v.detected = detected
+		v.goconf.Version = detected.Version
+	}
+	v.versions = v.goconf.Versions
+	if len(v.versions) == 0 {
+		v.versions = []string{v.goconf.Version}
+	}
+	v.constraint = v.versions[0]
 	v.releasesPath = filepath.Join(v.wsconf.InstallationDir, "go", "releases")
-	v.downloadPath = filepath.Join(v.releasesPath, v.tarName)
-	v.binPath = filepath.Join(v.installPath, "go", "bin", "go")
+	// versions which are not locked yet are resolved by setup
+	if err := v.resolve(func(constraint string) (string, bool, error) {
+		return resolver.Locked(wsconf, resolver.Go, constraint)
+	}); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+// resolve creates the releases of the configured versions, constraints which
+// lookup does not resolve are kept as version
+func (v *plugin) resolve(lookup func(string) (string, bool, error)) error {
+	v.resolved = true
+	get := func(constraint string) (string, error) {
+		version, ok, err := lookup(constraint)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			version, v.resolved = constraint, false
+		}
+		return version, nil
+	}
+	v.releases = make([]*release, len(v.versions))
+	for i, constraint := range v.versions {
+		version, err := get(constraint)
+		if err != nil {
+			return err
+		}
+		r := &release{version: version}
+		r.tarName = fmt.Sprintf("go%s.%s-%s.tar.gz", version, runtime.GOOS, runtime.GOARCH)
+		r.installURL = fmt.Sprintf("%s/%s", goBinaryHost, r.tarName)
+		r.installPath = filepath.Join(v.wsconf.InstallationDir, "go", version)
+		r.downloadPath = filepath.Join(v.releasesPath, r.tarName)
+		r.binPath = filepath.Join(r.installPath, "go", "bin", "go")
+		v.releases[i] = r
+	}
+	v.version = v.releases[len(v.releases)-1].version
+	if len(v.goconf.Default) > 0 {
+		version, err := get(v.goconf.Default)
+		if err != nil {
+			return err
+		}
+		v.version = version
+	}
+	if v.resolved && !v.installs(v.version) {
+		return fmt.Errorf("default go version %s is not listed in versions", v.version)
+	}
+	v.shim = nil
+	if len(v.releases) > 1 {
+		v.shim = &shim.Shim{
+			Name:     "go",
+			Dir:      filepath.Join(v.wsconf.InstallationDir, "go", "shims"),
+			Commands: []string{"go", "gofmt"},
+			Files: []shim.File{
+				{Name: ".go-version", Expression: `s/^\([0-9][^ ]*\).*/\1/p`},
+				{Name: "go.mod", Expression: `s/^toolchain go\([^ ]*\).*/\1/p`},
+				{Name: "go.mod", Expression: `s/^go \([0-9][^ ]*\).*/\1/p`},
+				shim.ToolVersions("golang"),
+			},
+			Versions: make(map[string]string),
+			Default:  v.version,
+		}
+		for _, r := range v.releases {
+			v.shim.Versions[r.version] = filepath.Dir(r.binPath)
+		}
+	}
+	return nil
+}
+
+// Resolved reports whether all versions are exact or locked
+func (v *plugin) Resolved() bool {
+	return v.resolved
+}
+
+// Resolve resolves the versions against the release index and locks them
+func (v *plugin) Resolve() error {
+	return v.resolve(func(constraint string) (string, bool, error) {
+		version, err := resolver.Lookup(v.wsconf, resolver.Go, constraint)
+		return version, err == nil, err
+	})
+}
+
+func (v *plugin) installs(version string) bool {
+	for _, r := range v.releases {
+		if r.version == version {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *plugin) SetupTasks() ext.SetupTasks {
-	if fs.Exists(v.binPath) {
-		return nil
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	for _, r := range v.releases {
+		r := r
+		suffix := ""
+		if len(v.releases) > 1 {
+			suffix = " " + r.version
+		}
+		path := filepath.Join(r.installPath, "go")
+		status, err := installation.Check(path, r.binPath)
+		if err != nil {
+			return ext.SetupTasks{ext.Failure("checking"+suffix, err)}
+		}
+		if status == ext.Installed {
+			continue
+		}
+		if status == ext.Adoptable {
+			tasks = append(tasks, ext.Adopt("recording"+suffix, path))
+			continue
+		}
+		tasks = append(tasks,
+			ext.Procedure("initializing"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				return fs.Mkdir(r.installPath, v.releasesPath)
+			}),
+			ext.Procedure("downloading"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				cb := make(chan int)
+				go func() {
+					var lp int
+					for progress := range cb {
+						bar.IncrBy(progress - lp)
+						lp = progress
+					}
+				}()
+				return downloader.New(r.installURL, r.downloadPath).Start(ctx, cb)
+			}),
+			ext.Procedure("unpacking"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				return unpack.Unarchive(ctx, archiver.NewTarGz(), r.downloadPath, r.installPath)
+			}, ext.Installs(path)),
+		)
 	}
-	return ext.SetupTasks{
-		ext.Procedure("initializing", func(bar ext.ProgressBar) error {
-			return fs.Mkdir(v.installPath, v.releasesPath)
-		}),
-		ext.Procedure("downloading", func(bar ext.ProgressBar) error {
-			cb := make(chan int)
-			go func() {
-				var lp int
-				for progress := range cb {
-					bar.IncrBy(progress - lp)
-					lp = progress
-				}
-			}()
-			return downloader.New(v.installURL, v.downloadPath).Start(cb)
-		}),
-		ext.Procedure("unpacking", func(bar ext.ProgressBar) error {
-			if err := archiver.NewTarGz().Unarchive(v.downloadPath, v.installPath); err != nil {
-				return err
-			}
-			return nil
-		}),
+	if v.shim != nil && v.shim.Outdated() {
+		tasks = append(tasks, ext.Procedure("linking shims", func(ctx context.Context, bar ext.ProgressBar) error {
+			return v.shim.Write()
+		}))
 	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks
 }
 
 func (v *plugin) Environment() map[string]string {
@@ -123,12 +273,69 @@ func (v *plugin) Sources() []string {
 }
 
 func (v *plugin) Paths() []string {
-	return []string{filepath.Join(v.installPath, "go", "bin")}
+	if v.shim != nil {
+		return []string{v.shim.Dir}
+	}
+	return []string{filepath.Join(v.releases[0].installPath, "go", "bin")}
+}
+
+func (v *plugin) Owns() []string {
+	paths := make([]string, 0)
+	for _, r := range v.releases {
+		paths = append(paths, r.installPath, r.downloadPath)
+	}
+	if v.shim != nil {
+		paths = append(paths, v.shim.Dir)
+	}
+	return paths
+}
+
+func (v *plugin) Installation() *ext.Installation {
+	installation := &ext.Installation{Record: ext.StatePath(v.wsconf, "go")}
+	versions := make([]string, len(v.releases))
+	for i, r := range v.releases {
+		versions[i] = r.version
+		installation.Paths = append(installation.Paths, filepath.Join(r.installPath, "go"))
+	}
+	installation.Version = strings.Join(versions, ", ")
+	return installation
+}
+
+func (v *plugin) Features() []*ext.Feature {
+	return []*ext.Feature{
+		{ID: "ghcr.io/devcontainers/features/go:1", Options: map[string]interface{}{"version": v.version}},
+	}
+}
+
+func (v *plugin) Upgrade(policy resolver.Policy) (*resolver.Upgrade, error) {
+	if v.detected != nil || len(v.releases) > 1 {
+		return nil, nil
+	}
+	return resolver.Check(v.wsconf, resolver.Go, v.constraint, v.version, policy)
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	return []string{"go"}
+}
+
+func (v *plugin) Detected() *detect.Result {
+	return v.detected
 }
 
 func (v *plugin) String() string {
-	if v.goconf != nil && len(v.goconf.Version) > 0 {
-		return fmt.Sprintf("go %s", v.goconf.Version)
+	if len(v.releases) > 1 {
+		versions := make([]string, len(v.releases))
+		for i, r := range v.releases {
+			versions[i] = r.version
+		}
+		return fmt.Sprintf("go %s", strings.Join(versions, ", "))
+	}
+	if len(v.version) > 0 {
+		return fmt.Sprintf("go %s", v.version)
 	}
 	return "go"
 }
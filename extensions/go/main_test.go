@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+const index = `[
+	{"version": "go1.22.1", "stable": true},
+	{"version": "go1.22.0", "stable": true},
+	{"version": "go1.21.3", "stable": true},
+	{"version": "go1.20", "stable": true}
+]`
+
+func TestDetected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, index)
+	}))
+	defer srv.Close()
+	url := resolver.Go.URL
+	resolver.Go.URL = srv.URL
+	defer func() { resolver.Go.URL = url }()
+
+	tests := []struct {
+		name     string
+		gomod    string
+		resolved bool
+		want     string
+	}{
+		{"minor", "go 1.22\n", false, "1.22.1"},
+		{"toolchain", "go 1.21\ntoolchain go1.21.3\n", true, "1.21.3"},
+		{"release without patch", "go 1.20\n", false, "1.20"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\n"+test.gomod), 0644)
+			v := new(plugin)
+			ok, err := v.Init(&workspaceconfig.Config{
+				WorkingDir:      dir,
+				InstallationDir: filepath.Join(dir, ".installation"),
+				LockfilePath:    filepath.Join(dir, ".workspace.lock"),
+				Src:             []byte("workspace:\n  with:\n    go:\n      version: auto\n"),
+			})
+			if err != nil || !ok {
+				t.Fatalf("Init = %v, %v", ok, err)
+			}
+			if v.Detected() == nil || v.Detected().Source != "go.mod" {
+				t.Errorf("detected = %+v, want go.mod", v.Detected())
+			}
+			if v.Resolved() != test.resolved {
+				t.Fatalf("Resolved = %v, want %v", v.Resolved(), test.resolved)
+			}
+			if err := v.Resolve(); err != nil {
+				t.Fatal(err)
+			}
+			if v.version != test.want {
+				t.Errorf("version = %s, want %s", v.version, test.want)
+			}
+		})
+	}
+}
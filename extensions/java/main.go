@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mholt/archiver"
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/util/downloader"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// Example of .workspace.yaml:
+//
+// workspace:
+//   shell:
+//     program: /bin/zsh
+//   with:
+//     java:
+//       version: 17
+//       distribution: temurin
+//       maven: 3.9.6
+//       gradle: 8.5
+
+var (
+	temurinBinaryHost = "https://api.adoptium.net/v3/binary/latest"
+	mavenBinaryHost   = "https://archive.apache.org/dist/maven/maven-3"
+	gradleBinaryHost  = "https://services.gradle.org/distributions"
+)
+
+var temurinPlatforms = map[string]string{
+	"linux/amd64":  "linux/x64",
+	"linux/arm64":  "linux/aarch64",
+	"darwin/amd64": "mac/x64",
+	"darwin/arm64": "mac/aarch64",
+}
+
+type plugin struct {
+	wsconf         *workspaceconfig.Config
+	javaconf       *javaConfig
+	releasesPath   string
+	jdkURL         string
+	jdkPath        string
+	jdkDownload    string
+	mavenURL       string
+	mavenPath      string
+	mavenDownload  string
+	gradleURL      string
+	gradlePath     string
+	gradleDownload string
+	gradleHome     string
+	mavenRepo      string
+}
+
+type config struct {
+	Workspace *workspaceConfig `yaml:"workspace"`
+}
+
+type workspaceConfig struct {
+	With *withConfig `yaml:"with"`
+}
+
+type withConfig struct {
+	Java *javaConfig `yaml:"java"`
+}
+
+type javaConfig struct {
+	Version      string `yaml:"version"`
+	Distribution string `yaml:"distribution"`
+	Maven        string `yaml:"maven"`
+	Gradle       string `yaml:"gradle"`
+}
+
+func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
+	var javaconf *config
+	if err := yaml.Unmarshal(wsconf.Src, &javaconf); err != nil {
+		return false, err
+	}
+	if javaconf == nil || javaconf.Workspace.With.Java == nil || len(javaconf.Workspace.With.Java.Version) == 0 {
+		return false, nil
+	}
+	platform, ok := temurinPlatforms[fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)]
+	if !ok {
+		return false, fmt.Errorf("java is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	v.wsconf = wsconf
+	v.javaconf = javaconf.Workspace.With.Java
+	switch v.javaconf.Distribution {
+	case "", "temurin":
+	default:
+		return false, fmt.Errorf("java distribution '%s' is not supported", v.javaconf.Distribution)
+	}
+	v.releasesPath = filepath.Join(v.wsconf.InstallationDir, "java", "releases")
+	v.jdkURL = fmt.Sprintf("%s/%s/ga/%s/jdk/hotspot/normal/eclipse", temurinBinaryHost, v.javaconf.Version, platform)
+	v.jdkPath = filepath.Join(v.wsconf.InstallationDir, "java", "jdk", v.javaconf.Version)
+	v.jdkDownload = filepath.Join(v.releasesPath, fmt.Sprintf("temurin-%s.tar.gz", v.javaconf.Version))
+	if version := v.javaconf.Maven; len(version) > 0 {
+		v.mavenURL = fmt.Sprintf("%s/%s/binaries/apache-maven-%s-bin.tar.gz", mavenBinaryHost, version, version)
+		v.mavenPath = filepath.Join(v.wsconf.InstallationDir, "java", "maven")
+		v.mavenDownload = filepath.Join(v.releasesPath, filepath.Base(v.mavenURL))
+		v.mavenRepo = filepath.Join(v.wsconf.WorkingDir, ".m2", "repository")
+	}
+	if version := v.javaconf.Gradle; len(version) > 0 {
+		v.gradleURL = fmt.Sprintf("%s/gradle-%s-bin.zip", gradleBinaryHost, version)
+		v.gradlePath = filepath.Join(v.wsconf.InstallationDir, "java", "gradle")
+		v.gradleDownload = filepath.Join(v.releasesPath, filepath.Base(v.gradleURL))
+		v.gradleHome = filepath.Join(v.wsconf.WorkingDir, ".gradle")
+	}
+	return true, nil
+}
+
+// javaHome returns the stable path of the jdk, the archive contains a
+// versioned directory (e.g jdk-17.0.9+9) which is linked after unpacking
+func (v *plugin) javaHome() string {
+	return filepath.Join(v.jdkPath, "current")
+}
+
+// release reports whether the unpacked jdk tree is a release of the requested
+// version, e.g jdk-17.0.9+9 for 17 or jdk8u392-b08 for 8
+func (v *plugin) release(tree string) bool {
+	version := v.javaconf.Version
+	if strings.HasPrefix(tree, "jdk"+version+"u") {
+		return true
+	}
+	rest := strings.TrimPrefix(tree, "jdk-"+version)
+	return rest != tree && (len(rest) == 0 || rest[0] == '.' || rest[0] == '+')
+}
+
+// link points the stable jdk path to the unpacked tree of the requested
+// version, the jdk path may still contain trees of previous releases
+func (v *plugin) link(trees []string) error {
+	for _, tree := range trees {
+		if !v.release(tree) {
+			continue
+		}
+		for _, home := range []string{tree, filepath.Join(tree, "Contents", "Home")} {
+			home = filepath.Join(v.jdkPath, home)
+			if fs.Exists(filepath.Join(home, "bin", "java")) {
+				return fs.Symlink(home, v.javaHome())
+			}
+		}
+	}
+	return fmt.Errorf("unable to find java %s in %s", v.javaconf.Version, filepath.Base(v.jdkDownload))
+}
+
+func (v *plugin) mavenHome() string {
+	return filepath.Join(v.mavenPath, fmt.Sprintf("apache-maven-%s", v.javaconf.Maven))
+}
+
+func (v *plugin) gradleDir() string {
+	return filepath.Join(v.gradlePath, fmt.Sprintf("gradle-%s", v.javaconf.Gradle))
+}
+
+func download(url, dest string) ext.SetupTaskHandler {
+	return func(ctx context.Context, bar ext.ProgressBar) error {
+		cb := make(chan int)
+		go func() {
+			var lp int
+			for progress := range cb {
+				bar.IncrBy(progress - lp)
+				lp = progress
+			}
+		}()
+		return downloader.New(url, dest).Start(ctx, cb)
+	}
+}
+
+func (v *plugin) SetupTasks() ext.SetupTasks {
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	var (
+		java   = filepath.Join(v.javaHome(), "bin", "java")
+		mvn    = filepath.Join(v.mavenHome(), "bin", "mvn")
+		gradle = filepath.Join(v.gradleDir(), "bin", "gradle")
+	)
+	jdkStatus, err := installation.Check(v.jdkPath, java)
+	if err != nil {
+		return ext.SetupTasks{ext.Failure("checking jdk", err)}
+	}
+	mavenStatus, err := installation.Check(v.mavenHome(), mvn)
+	if err != nil {
+		return ext.SetupTasks{ext.Failure("checking maven", err)}
+	}
+	gradleStatus, err := installation.Check(v.gradleDir(), gradle)
+	if err != nil {
+		return ext.SetupTasks{ext.Failure("checking gradle", err)}
+	}
+	switch jdkStatus {
+	case ext.Installed:
+	case ext.Adoptable:
+		tasks = append(tasks, ext.Adopt("recording jdk", v.jdkPath))
+	default:
+		tasks = append(tasks,
+			ext.Procedure("initializing", func(ctx context.Context, bar ext.ProgressBar) error {
+				return fs.Mkdir(v.jdkPath, v.releasesPath)
+			}),
+			ext.Procedure("downloading jdk", download(v.jdkURL, v.jdkDownload)),
+			ext.Procedure("unpacking jdk", func(ctx context.Context, bar ext.ProgressBar) error {
+				trees, err := unpack.UnarchiveTrees(ctx, archiver.NewTarGz(), v.jdkDownload, v.jdkPath)
+				if err != nil {
+					return err
+				}
+				return v.link(trees)
+			}, ext.Installs(v.jdkPath)),
+		)
+	}
+	switch {
+	case len(v.mavenURL) == 0, mavenStatus == ext.Installed:
+	case mavenStatus == ext.Adoptable:
+		tasks = append(tasks, ext.Adopt("recording maven", v.mavenHome()))
+	default:
+		tasks = append(tasks,
+			ext.Procedure("downloading maven", func(ctx context.Context, bar ext.ProgressBar) error {
+				if err := fs.Mkdir(v.mavenPath, v.releasesPath); err != nil {
+					return err
+				}
+				return download(v.mavenURL, v.mavenDownload)(ctx, bar)
+			}),
+			ext.Procedure("unpacking maven", func(ctx context.Context, bar ext.ProgressBar) error {
+				return unpack.Unarchive(ctx, archiver.NewTarGz(), v.mavenDownload, v.mavenPath)
+			}, ext.Installs(v.mavenHome())),
+		)
+	}
+	switch {
+	case len(v.gradleURL) == 0, gradleStatus == ext.Installed:
+	case gradleStatus == ext.Adoptable:
+		tasks = append(tasks, ext.Adopt("recording gradle", v.gradleDir()))
+	default:
+		tasks = append(tasks,
+			ext.Procedure("downloading gradle", func(ctx context.Context, bar ext.ProgressBar) error {
+				if err := fs.Mkdir(v.gradlePath, v.releasesPath); err != nil {
+					return err
+				}
+				return download(v.gradleURL, v.gradleDownload)(ctx, bar)
+			}),
+			ext.Procedure("unpacking gradle", func(ctx context.Context, bar ext.ProgressBar) error {
+				return unpack.Unarchive(ctx, archiver.NewZip(), v.gradleDownload, v.gradlePath)
+			}, ext.Installs(v.gradleDir())),
+		)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks
+}
+
+func (v *plugin) Environment() map[string]string {
+	env := map[string]string{
+		"JAVA_HOME": v.javaHome(),
+	}
+	if len(v.mavenURL) > 0 {
+		env["M2_HOME"] = v.mavenHome()
+		env["MAVEN_OPTS"] = fmt.Sprintf("-Dmaven.repo.local=%s", v.mavenRepo)
+	}
+	if len(v.gradleURL) > 0 {
+		env["GRADLE_USER_HOME"] = v.gradleHome
+	}
+	return env
+}
+
+func (v *plugin) Aliases() map[string]string {
+	return nil
+}
+
+func (v *plugin) Sources() []string {
+	return nil
+}
+
+func (v *plugin) Paths() []string {
+	paths := []string{filepath.Join(v.javaHome(), "bin")}
+	if len(v.mavenURL) > 0 {
+		paths = append(paths, filepath.Join(v.mavenHome(), "bin"))
+	}
+	if len(v.gradleURL) > 0 {
+		paths = append(paths, filepath.Join(v.gradleDir(), "bin"))
+	}
+	return paths
+}
+
+func (v *plugin) Owns() []string {
+	paths := []string{v.jdkPath, v.jdkDownload}
+	if len(v.mavenURL) > 0 {
+		paths = append(paths, v.mavenHome(), v.mavenDownload)
+	}
+	if len(v.gradleURL) > 0 {
+		paths = append(paths, v.gradleDir(), v.gradleDownload)
+	}
+	return paths
+}
+
+func (v *plugin) Installation() *ext.Installation {
+	installation := &ext.Installation{
+		Record:  ext.StatePath(v.wsconf, "java"),
+		Version: v.javaconf.Version,
+		Paths:   []string{v.jdkPath},
+	}
+	if len(v.mavenURL) > 0 {
+		installation.Paths = append(installation.Paths, v.mavenHome())
+	}
+	if len(v.gradleURL) > 0 {
+		installation.Paths = append(installation.Paths, v.gradleDir())
+	}
+	return installation
+}
+
+func (v *plugin) Features() []*ext.Feature {
+	options := map[string]interface{}{"version": v.javaconf.Version}
+	if len(v.mavenURL) > 0 {
+		options["installMaven"] = true
+		options["mavenVersion"] = v.javaconf.Maven
+	}
+	if len(v.gradleURL) > 0 {
+		options["installGradle"] = true
+		options["gradleVersion"] = v.javaconf.Gradle
+	}
+	return []*ext.Feature{
+		{ID: "ghcr.io/devcontainers/features/java:1", Options: options},
+	}
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	provides := []string{"java"}
+	if len(v.mavenURL) > 0 {
+		provides = append(provides, "maven")
+	}
+	if len(v.gradleURL) > 0 {
+		provides = append(provides, "gradle")
+	}
+	return provides
+}
+
+func (v *plugin) String() string {
+	if v.javaconf != nil && len(v.javaconf.Version) > 0 {
+		return fmt.Sprintf("java %s", v.javaconf.Version)
+	}
+	return "java"
+}
+
+// Export is a plugin instance used for workspace
+var Export = ext.Extension(new(plugin))
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+func workspace(t *testing.T, with string) *workspaceconfig.Config {
+	t.Helper()
+	dir := t.TempDir()
+	return &workspaceconfig.Config{
+		Namespace:       "test",
+		WorkingDir:      dir,
+		InstallationDir: filepath.Join(dir, ".installation"),
+		Src:             []byte("workspace:\n  with:\n" + with),
+	}
+}
+
+func TestInit(t *testing.T) {
+	platform := temurinPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	if len(platform) == 0 {
+		t.Skipf("java is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	tests := []struct {
+		name     string
+		with     string
+		ok       bool
+		provides []string
+		err      string
+	}{
+		{"not configured", "    go:\n      version: 1.21.3\n", false, nil, ""},
+		{"jdk", "    java:\n      version: 17\n", true, []string{"java"}, ""},
+		{"build tools", "    java:\n      version: 17\n      distribution: temurin\n      maven: 3.9.6\n      gradle: \"8.5\"\n", true, []string{"java", "maven", "gradle"}, ""},
+		{"distribution", "    java:\n      version: 17\n      distribution: zulu\n", false, nil, "distribution 'zulu' is not supported"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := new(plugin)
+			ok, err := v.Init(workspace(t, test.with))
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Init err = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != test.ok {
+				t.Fatalf("Init = %v, want %v", ok, test.ok)
+			}
+			if !ok {
+				return
+			}
+			if want := temurinBinaryHost + "/17/ga/" + platform + "/jdk/hotspot/normal/eclipse"; v.jdkURL != want {
+				t.Errorf("jdk URL = %s, want %s", v.jdkURL, want)
+			}
+			if got := strings.Join(v.Provides(), " "); got != strings.Join(test.provides, " ") {
+				t.Errorf("Provides = %s, want %v", got, test.provides)
+			}
+		})
+	}
+}
+
+func TestInitUnsupported(t *testing.T) {
+	key := runtime.GOOS + "/" + runtime.GOARCH
+	if platform, ok := temurinPlatforms[key]; ok {
+		delete(temurinPlatforms, key)
+		defer func() { temurinPlatforms[key] = platform }()
+	}
+	_, err := new(plugin).Init(workspace(t, "    java:\n      version: 17\n"))
+	if want := fmt.Sprintf("not supported on %s", key); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Init err = %v, want %q", err, want)
+	}
+}
+
+func TestLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		trees   []string
+		want    string
+	}{
+		{"release", "17", []string{"jdk-17.0.10+7"}, "jdk-17.0.10+7"},
+		{"exact", "17.0.9+9", []string{"jdk-17.0.9+9"}, "jdk-17.0.9+9"},
+		{"feature release", "21", []string{"jdk-21+35"}, "jdk-21+35"},
+		{"java 8", "8", []string{"jdk8u392-b08"}, "jdk8u392-b08"},
+		{"mac", "17", []string{"jdk-17.0.10+7"}, "jdk-17.0.10+7/Contents/Home"},
+		{"other version", "11", []string{"jdk-17.0.10+7"}, ""},
+		{"prefix", "1", []string{"jdk-17.0.10+7"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := &plugin{javaconf: &javaConfig{Version: test.version}, jdkPath: t.TempDir()}
+			// trees of previous releases are left in the jdk path
+			for _, tree := range append(test.trees, "jdk-17.0.9+9", "jdk8u382-b05") {
+				bin := filepath.Join(v.jdkPath, tree, "bin")
+				if test.name == "mac" && tree == test.trees[0] {
+					bin = filepath.Join(v.jdkPath, tree, "Contents", "Home", "bin")
+				}
+				os.MkdirAll(bin, 0755)
+				ioutil.WriteFile(filepath.Join(bin, "java"), nil, 0755)
+			}
+			err := v.link(test.trees)
+			if len(test.want) == 0 {
+				if err == nil {
+					t.Fatalf("link succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.Readlink(v.javaHome()); got != filepath.Join(v.jdkPath, test.want) {
+				t.Errorf("java home links to %s, want %s", got, test.want)
+			}
+		})
+	}
+}
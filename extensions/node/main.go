@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/mholt/archiver"
+	"github.com/samuelngs/dem/pkg/detect"
 	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/shim"
 	"github.com/samuelngs/dem/pkg/util/downloader"
 	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
 	"github.com/samuelngs/dem/pkg/workspaceconfig"
 	"gopkg.in/yaml.v2"
 )
@@ -20,19 +26,37 @@ import (
 //     program: /bin/zsh
 //   with:
 //     node:
-//       version: 10.14.2
+//       version: 10.14.2 # or 10.x, ^10, latest, lts, auto
+//
+// Multiple versions are switched per directory by .nvmrc or .node-version:
+//
+//   with:
+//     node:
+//       versions: [18, 20]
+//       default: 20
 
 var nodeBinaryHost = "https://nodejs.org/dist"
 
 type plugin struct {
 	wsconf       *workspaceconfig.Config
 	nodeconf     *nodeConfig
+	detected     *detect.Result
+	constraint   string
+	versions     []string
+	version      string
+	resolved     bool
+	releases     []*release
+	installPath  string
+	releasesPath string
+	shim         *shim.Shim
+}
+
+type release struct {
+	version      string
 	binPath      string
 	refName      string
 	tarName      string
-	releasesPath string
 	installURL   string
-	installPath  string
 	downloadPath string
 }
 
@@ -49,7 +73,9 @@ type withConfig struct {
 }
 
 type nodeConfig struct {
-	Version string `yaml:"version"`
+	Version  string   `yaml:"version"`
+	Versions []string `yaml:"versions"`
+	Default  string   `yaml:"default"`
 }
 
 func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
@@ -57,47 +83,170 @@ func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
 	if err := yaml.Unmarshal(wsconf.Src, &nodeconf); err != nil {
 		return false, err
 	}
-	if nodeconf == nil || nodeconf.Workspace.With.Node == nil || len(nodeconf.Workspace.With.Node.Version) == 0 {
+	if nodeconf == nil || nodeconf.Workspace.With.Node == nil {
+		return false, nil
+	}
+	if len(nodeconf.Workspace.With.Node.Version) == 0 && len(nodeconf.Workspace.With.Node.Versions) == 0 {
 		return false, nil
 	}
 	v.wsconf = wsconf
 	v.nodeconf = nodeconf.Workspace.With.Node
-	v.refName = fmt.Sprintf("node-v%s-%s-x64", v.nodeconf.Version, runtime.GOOS)
-	v.tarName = fmt.Sprintf("%s.tar.gz", v.refName)
-	v.installURL = fmt.Sprintf("%s/v%s/%s", nodeBinaryHost, v.nodeconf.Version, v.tarName)
+	if v.nodeconf.Version == detect.Auto {
+		detected, err := detect.Node(wsconf.WorkingDir)
+		if err != nil {
+			return false, err
+		}
+		v.detected = detected
+		v.nodeconf.Version = detected.Version
+	}
+	v.versions = v.nodeconf.Versions
+	if len(v.versions) == 0 {
+		v.versions = []string{v.nodeconf.Version}
+	}
+	v.constraint = v.versions[0]
 	v.installPath = filepath.Join(v.wsconf.InstallationDir, "node")
 	v.releasesPath = filepath.Join(v.installPath, "releases")
-	v.downloadPath = filepath.Join(v.releasesPath, v.tarName)
-	v.binPath = filepath.Join(v.installPath, v.refName, "bin", "node")
+	// versions which are not locked yet are resolved by setup
+	if err := v.resolve(func(constraint string) (string, bool, error) {
+		return resolver.Locked(wsconf, resolver.Node, constraint)
+	}); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+// resolve creates the releases of the configured versions, constraints which
+// lookup does not resolve are kept as version
+func (v *plugin) resolve(lookup func(string) (string, bool, error)) error {
+	v.resolved = true
+	get := func(constraint string) (string, error) {
+		version, ok, err := lookup(constraint)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			version, v.resolved = constraint, false
+		}
+		return version, nil
+	}
+	v.releases = make([]*release, len(v.versions))
+	for i, constraint := range v.versions {
+		version, err := get(constraint)
+		if err != nil {
+			return err
+		}
+		r := &release{version: version}
+		r.refName = fmt.Sprintf("node-v%s-%s-x64", version, runtime.GOOS)
+		r.tarName = fmt.Sprintf("%s.tar.gz", r.refName)
+		r.installURL = fmt.Sprintf("%s/v%s/%s", nodeBinaryHost, version, r.tarName)
+		r.downloadPath = filepath.Join(v.releasesPath, r.tarName)
+		r.binPath = filepath.Join(v.installPath, r.refName, "bin", "node")
+		v.releases[i] = r
+	}
+	v.version = v.releases[len(v.releases)-1].version
+	if len(v.nodeconf.Default) > 0 {
+		version, err := get(v.nodeconf.Default)
+		if err != nil {
+			return err
+		}
+		v.version = version
+	}
+	if v.resolved && !v.installs(v.version) {
+		return fmt.Errorf("default node version %s is not listed in versions", v.version)
+	}
+	v.shim = nil
+	if len(v.releases) > 1 {
+		v.shim = &shim.Shim{
+			Name:     "node",
+			Dir:      filepath.Join(v.installPath, "shims"),
+			Commands: []string{"node", "npm", "npx"},
+			Files: []shim.File{
+				{Name: ".nvmrc", Expression: `s/^v\{0,1\}\([0-9][^ ]*\).*/\1/p`},
+				{Name: ".node-version", Expression: `s/^v\{0,1\}\([0-9][^ ]*\).*/\1/p`},
+				shim.ToolVersions("nodejs"),
+			},
+			Versions: make(map[string]string),
+			Default:  v.version,
+		}
+		for _, r := range v.releases {
+			v.shim.Versions[r.version] = filepath.Dir(r.binPath)
+		}
+	}
+	return nil
+}
+
+// Resolved reports whether all versions are exact or locked
+func (v *plugin) Resolved() bool {
+	return v.resolved
+}
+
+// Resolve resolves the versions against the release index and locks them
+func (v *plugin) Resolve() error {
+	return v.resolve(func(constraint string) (string, bool, error) {
+		version, err := resolver.Lookup(v.wsconf, resolver.Node, constraint)
+		return version, err == nil, err
+	})
+}
+
+func (v *plugin) installs(version string) bool {
+	for _, r := range v.releases {
+		if r.version == version {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *plugin) SetupTasks() ext.SetupTasks {
-	if fs.Exists(v.binPath) {
-		return nil
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	for _, r := range v.releases {
+		r := r
+		suffix := ""
+		if len(v.releases) > 1 {
+			suffix = " " + r.version
+		}
+		path := filepath.Join(v.installPath, r.refName)
+		status, err := installation.Check(path, r.binPath)
+		if err != nil {
+			return ext.SetupTasks{ext.Failure("checking"+suffix, err)}
+		}
+		if status == ext.Installed {
+			continue
+		}
+		if status == ext.Adoptable {
+			tasks = append(tasks, ext.Adopt("recording"+suffix, path))
+			continue
+		}
+		tasks = append(tasks,
+			ext.Procedure("initializing"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				return fs.Mkdir(v.installPath, v.releasesPath)
+			}),
+			ext.Procedure("downloading"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				cb := make(chan int)
+				go func() {
+					var lp int
+					for progress := range cb {
+						bar.IncrBy(progress - lp)
+						lp = progress
+					}
+				}()
+				return downloader.New(r.installURL, r.downloadPath).Start(ctx, cb)
+			}),
+			ext.Procedure("unpacking"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				return unpack.Unarchive(ctx, archiver.NewTarGz(), r.downloadPath, v.installPath)
+			}, ext.Installs(path)),
+		)
 	}
-	return ext.SetupTasks{
-		ext.Procedure("initializing", func(bar ext.ProgressBar) error {
-			return fs.Mkdir(v.installPath, v.releasesPath)
-		}),
-		ext.Procedure("downloading", func(bar ext.ProgressBar) error {
-			cb := make(chan int)
-			go func() {
-				var lp int
-				for progress := range cb {
-					bar.IncrBy(progress - lp)
-					lp = progress
-				}
-			}()
-			return downloader.New(v.installURL, v.downloadPath).Start(cb)
-		}),
-		ext.Procedure("unpacking", func(bar ext.ProgressBar) error {
-			if err := archiver.NewTarGz().Unarchive(v.downloadPath, v.installPath); err != nil {
-				return err
-			}
-			return nil
-		}),
+	if v.shim != nil && v.shim.Outdated() {
+		tasks = append(tasks, ext.Procedure("linking shims", func(ctx context.Context, bar ext.ProgressBar) error {
+			return v.shim.Write()
+		}))
 	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks
 }
 
 func (v *plugin) Environment() map[string]string {
@@ -113,12 +262,69 @@ func (v *plugin) Sources() []string {
 }
 
 func (v *plugin) Paths() []string {
-	return []string{filepath.Join(v.installPath, v.refName, "bin")}
+	if v.shim != nil {
+		return []string{v.shim.Dir}
+	}
+	return []string{filepath.Dir(v.releases[0].binPath)}
+}
+
+func (v *plugin) Owns() []string {
+	paths := make([]string, 0)
+	for _, r := range v.releases {
+		paths = append(paths, filepath.Join(v.installPath, r.refName), r.downloadPath)
+	}
+	if v.shim != nil {
+		paths = append(paths, v.shim.Dir)
+	}
+	return paths
+}
+
+func (v *plugin) Installation() *ext.Installation {
+	installation := &ext.Installation{Record: ext.StatePath(v.wsconf, "node")}
+	versions := make([]string, len(v.releases))
+	for i, r := range v.releases {
+		versions[i] = r.version
+		installation.Paths = append(installation.Paths, filepath.Join(v.installPath, r.refName))
+	}
+	installation.Version = strings.Join(versions, ", ")
+	return installation
+}
+
+func (v *plugin) Features() []*ext.Feature {
+	return []*ext.Feature{
+		{ID: "ghcr.io/devcontainers/features/node:1", Options: map[string]interface{}{"version": v.version}},
+	}
+}
+
+func (v *plugin) Upgrade(policy resolver.Policy) (*resolver.Upgrade, error) {
+	if v.detected != nil || len(v.releases) > 1 {
+		return nil, nil
+	}
+	return resolver.Check(v.wsconf, resolver.Node, v.constraint, v.version, policy)
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	return []string{"node", "npm"}
+}
+
+func (v *plugin) Detected() *detect.Result {
+	return v.detected
 }
 
 func (v *plugin) String() string {
-	if v.nodeconf != nil && len(v.nodeconf.Version) > 0 {
-		return fmt.Sprintf("node %s", v.nodeconf.Version)
+	if len(v.releases) > 1 {
+		versions := make([]string, len(v.releases))
+		for i, r := range v.releases {
+			versions[i] = r.version
+		}
+		return fmt.Sprintf("node %s", strings.Join(versions, ", "))
+	}
+	if len(v.version) > 0 {
+		return fmt.Sprintf("node %s", v.version)
 	}
 	return "node"
 }
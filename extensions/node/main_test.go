@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+const index = `[
+	{"version": "v21.1.0", "lts": false},
+	{"version": "v20.9.0", "lts": "Iron"},
+	{"version": "v18.18.2", "lts": "Hydrogen"},
+	{"version": "v18.0.0", "lts": false}
+]`
+
+func TestDetected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, index)
+	}))
+	defer srv.Close()
+	url := resolver.Node.URL
+	resolver.Node.URL = srv.URL
+	defer func() { resolver.Node.URL = url }()
+
+	tests := []struct {
+		name     string
+		file     string
+		body     string
+		resolved bool
+		want     string
+	}{
+		{"major", ".nvmrc", "18\n", false, "18.18.2"},
+		{"lts", ".nvmrc", "lts/*\n", false, "20.9.0"},
+		{"exact", ".node-version", "v18.18.2\n", true, "18.18.2"},
+		{"engines", "package.json", `{"engines": {"node": ">=18"}}`, false, "18.18.2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ioutil.WriteFile(filepath.Join(dir, test.file), []byte(test.body), 0644)
+			v := new(plugin)
+			ok, err := v.Init(&workspaceconfig.Config{
+				WorkingDir:      dir,
+				InstallationDir: filepath.Join(dir, ".installation"),
+				LockfilePath:    filepath.Join(dir, ".workspace.lock"),
+				Src:             []byte("workspace:\n  with:\n    node:\n      version: auto\n"),
+			})
+			if err != nil || !ok {
+				t.Fatalf("Init = %v, %v", ok, err)
+			}
+			if v.Detected() == nil || v.Detected().Source != test.file {
+				t.Errorf("detected = %+v, want %s", v.Detected(), test.file)
+			}
+			if v.Resolved() != test.resolved {
+				t.Fatalf("Resolved = %v, want %v", v.Resolved(), test.resolved)
+			}
+			if err := v.Resolve(); err != nil {
+				t.Fatal(err)
+			}
+			if v.version != test.want {
+				t.Errorf("version = %s, want %s", v.version, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mholt/archiver"
+	"github.com/samuelngs/dem/pkg/detect"
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/shell"
+	"github.com/samuelngs/dem/pkg/util/downloader"
+	"github.com/samuelngs/dem/pkg/util/envcomposer"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// Example of .workspace.yaml:
+//
+// workspace:
+//   shell:
+//     program: /bin/zsh
+//   with:
+//     python:
+//       version: 3.11.6
+//       release: 20231002
+//       mirror: https://github.com/indygreg/python-build-standalone/releases/download
+//       requirements: requirements.txt
+
+var pythonBinaryHost = "https://github.com/indygreg/python-build-standalone/releases/download"
+
+var pythonPlatforms = map[string]string{
+	"linux/amd64":  "x86_64-unknown-linux-gnu",
+	"linux/arm64":  "aarch64-unknown-linux-gnu",
+	"darwin/amd64": "x86_64-apple-darwin",
+	"darwin/arm64": "aarch64-apple-darwin",
+}
+
+type plugin struct {
+	wsconf           *workspaceconfig.Config
+	pyconf           *pythonConfig
+	detected         *detect.Result
+	binPath          string
+	tarName          string
+	releasesPath     string
+	installURL       string
+	installPath      string
+	downloadPath     string
+	venvPath         string
+	requirementsPath string
+	checksumPath     string
+}
+
+type config struct {
+	Workspace *workspaceConfig `yaml:"workspace"`
+}
+
+type workspaceConfig struct {
+	With *withConfig `yaml:"with"`
+}
+
+type withConfig struct {
+	Python *pythonConfig `yaml:"python"`
+}
+
+type pythonConfig struct {
+	Version      string `yaml:"version"`
+	Release      string `yaml:"release"`
+	Mirror       string `yaml:"mirror"`
+	Requirements string `yaml:"requirements"`
+	LockFile     string `yaml:"lock_file"`
+}
+
+func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
+	var pyconf *config
+	if err := yaml.Unmarshal(wsconf.Src, &pyconf); err != nil {
+		return false, err
+	}
+	if pyconf == nil || pyconf.Workspace.With.Python == nil || len(pyconf.Workspace.With.Python.Version) == 0 {
+		return false, nil
+	}
+	platform, ok := pythonPlatforms[fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)]
+	if !ok {
+		return false, fmt.Errorf("python is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	v.wsconf = wsconf
+	v.pyconf = pyconf.Workspace.With.Python
+	if v.pyconf.Version == detect.Auto {
+		detected, err := detect.Python(wsconf.WorkingDir)
+		if err != nil {
+			return false, err
+		}
+		// there is no release index to resolve partial versions against
+		if !resolver.IsExact(detected.Version) {
+			return false, fmt.Errorf("python version %s of %s is not exact", detected.Version, detected.Source)
+		}
+		v.detected = detected
+		v.pyconf.Version = detected.Version
+	}
+	if len(v.pyconf.Release) == 0 {
+		return false, fmt.Errorf("python %s requires a standalone build release (e.g 20231002)", v.pyconf.Version)
+	}
+	host := pythonBinaryHost
+	if mirror := strings.TrimSuffix(v.pyconf.Mirror, "/"); len(mirror) > 0 {
+		host = mirror
+	}
+	v.tarName = fmt.Sprintf("cpython-%s+%s-%s-install_only.tar.gz", v.pyconf.Version, v.pyconf.Release, platform)
+	v.installURL = fmt.Sprintf("%s/%s/%s", host, v.pyconf.Release, v.tarName)
+	v.installPath = filepath.Join(v.wsconf.InstallationDir, "python", v.pyconf.Version)
+	v.releasesPath = filepath.Join(v.wsconf.InstallationDir, "python", "releases")
+	v.downloadPath = filepath.Join(v.releasesPath, v.tarName)
+	v.binPath = filepath.Join(v.installPath, "python", "bin", "python3")
+	v.venvPath = filepath.Join(v.wsconf.InstallationDir, "python", "venvs", v.pyconf.Version)
+	v.checksumPath = filepath.Join(v.venvPath, ".requirements.sha256")
+	switch {
+	case len(v.pyconf.LockFile) > 0:
+		v.requirementsPath = filepath.Join(v.wsconf.WorkingDir, v.pyconf.LockFile)
+	case len(v.pyconf.Requirements) > 0:
+		v.requirementsPath = filepath.Join(v.wsconf.WorkingDir, v.pyconf.Requirements)
+	}
+	return true, nil
+}
+
+// checksum returns the checksum of the requirements file, it is used to
+// decide whether the requirements have to be installed again
+func (v *plugin) checksum() string {
+	b, err := ioutil.ReadFile(v.requirementsPath)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// environment returns the environment of setup commands, pip runs compilers
+// and version control tools to build packages so the PATH of the user is kept
+func (v *plugin) environment() map[string]string {
+	config := v.wsconf
+	envcomposer := envcomposer.New()
+	envcomposer.Set("VIRTUAL_ENV", v.venvPath)
+	envcomposer.Set("PATH", strings.Trim(strings.Join(append(v.Paths(), os.Getenv("PATH")), ":"), ":"))
+	envcomposer.Set("SHELL", config.Workspace.Shell.Program)
+	envcomposer.Set("USER", config.Namespace)
+	envcomposer.Set("HOME", config.WorkingDir)
+	return envcomposer.AsMap()
+}
+
+func (v *plugin) run(ctx context.Context, command string, args ...string) error {
+	cmd := shell.New(command, args...)
+	cmd.SetDir(v.wsconf.WorkingDir)
+	cmd.SetEnv(v.environment())
+	cmd.SetStdin(nil)
+	cmd.SetStdout(nil)
+	cmd.SetStderr(nil)
+	cmd.SetContext(ctx)
+	return cmd.Run()
+}
+
+func (v *plugin) SetupTasks() ext.SetupTasks {
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	path := filepath.Join(v.installPath, "python")
+	status, err := installation.Check(path, v.binPath)
+	if err != nil {
+		return ext.SetupTasks{ext.Failure("checking", err)}
+	}
+	switch status {
+	case ext.Installed:
+	case ext.Adoptable:
+		tasks = append(tasks, ext.Adopt("recording", path))
+	default:
+		tasks = append(tasks,
+			ext.Procedure("initializing", func(ctx context.Context, bar ext.ProgressBar) error {
+				return fs.Mkdir(v.installPath, v.releasesPath)
+			}),
+			ext.Procedure("downloading", func(ctx context.Context, bar ext.ProgressBar) error {
+				cb := make(chan int)
+				go func() {
+					var lp int
+					for progress := range cb {
+						bar.IncrBy(progress - lp)
+						lp = progress
+					}
+				}()
+				return downloader.New(v.installURL, v.downloadPath).Start(ctx, cb)
+			}),
+			ext.Procedure("unpacking", func(ctx context.Context, bar ext.ProgressBar) error {
+				return unpack.Unarchive(ctx, archiver.NewTarGz(), v.downloadPath, v.installPath)
+			}, ext.Installs(path)),
+		)
+	}
+	if !fs.Exists(filepath.Join(v.venvPath, "bin", "python")) {
+		tasks = append(tasks, ext.Procedure("creating virtualenv", func(ctx context.Context, bar ext.ProgressBar) error {
+			// an interrupted virtualenv would look created
+			if err := v.run(ctx, v.binPath, "-m", "venv", v.venvPath); err != nil {
+				os.RemoveAll(v.venvPath)
+				return err
+			}
+			return nil
+		}))
+	}
+	if checksum := v.checksum(); len(checksum) > 0 && string(readFile(v.checksumPath)) != checksum {
+		tasks = append(tasks, ext.Procedure("installing requirements", func(ctx context.Context, bar ext.ProgressBar) error {
+			pip := filepath.Join(v.venvPath, "bin", "pip")
+			args := []string{"install", "--disable-pip-version-check", "-r", v.requirementsPath}
+			if len(v.pyconf.LockFile) > 0 {
+				args = append(args, "--require-hashes", "--no-deps")
+			}
+			if err := v.run(ctx, pip, args...); err != nil {
+				return err
+			}
+			return fs.WriteFile(v.checksumPath, []byte(checksum))
+		}))
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks
+}
+
+func (v *plugin) Environment() map[string]string {
+	return map[string]string{
+		"VIRTUAL_ENV": v.venvPath,
+	}
+}
+
+func (v *plugin) Aliases() map[string]string {
+	return nil
+}
+
+func (v *plugin) Sources() []string {
+	return nil
+}
+
+func (v *plugin) Paths() []string {
+	return []string{
+		filepath.Join(v.venvPath, "bin"),
+		filepath.Join(v.installPath, "python", "bin"),
+	}
+}
+
+func (v *plugin) Owns() []string {
+	return []string{v.installPath, v.downloadPath, v.venvPath}
+}
+
+// Installation records the python release, the virtualenv is modified by the
+// user and not verified
+func (v *plugin) Installation() *ext.Installation {
+	return &ext.Installation{
+		Record:  ext.StatePath(v.wsconf, "python"),
+		Version: v.pyconf.Version,
+		Paths:   []string{filepath.Join(v.installPath, "python")},
+	}
+}
+
+func (v *plugin) Features() []*ext.Feature {
+	return []*ext.Feature{
+		{ID: "ghcr.io/devcontainers/features/python:1", Options: map[string]interface{}{"version": v.pyconf.Version}},
+	}
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	return []string{"python", "pip"}
+}
+
+func (v *plugin) Detected() *detect.Result {
+	return v.detected
+}
+
+func (v *plugin) String() string {
+	if v.pyconf != nil && len(v.pyconf.Version) > 0 {
+		return fmt.Sprintf("python %s", v.pyconf.Version)
+	}
+	return "python"
+}
+
+func readFile(path string) []byte {
+	b, _ := ioutil.ReadFile(path)
+	return b
+}
+
+// Export is a plugin instance used for workspace
+var Export = ext.Extension(new(plugin))
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+func workspace(t *testing.T, with string) *workspaceconfig.Config {
+	t.Helper()
+	dir := t.TempDir()
+	return &workspaceconfig.Config{
+		Namespace:       "test",
+		WorkingDir:      dir,
+		InstallationDir: filepath.Join(dir, ".installation"),
+		Workspace:       &workspaceconfig.Workspace{Shell: &workspaceconfig.Shell{Program: "/bin/sh"}},
+		Src:             []byte("workspace:\n  with:\n" + with),
+	}
+}
+
+func TestInit(t *testing.T) {
+	platform := pythonPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	if len(platform) == 0 {
+		t.Skipf("python is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	tests := []struct {
+		name         string
+		with         string
+		ok           bool
+		url          string
+		requirements string
+		err          string
+	}{
+		{"not configured", "    go:\n      version: 1.21.3\n", false, "", "", ""},
+		{"no version", "    python: {}\n", false, "", "", ""},
+		{
+			"release",
+			"    python:\n      version: 3.11.6\n      release: \"20231002\"\n      requirements: requirements.txt\n",
+			true,
+			pythonBinaryHost + "/20231002/cpython-3.11.6+20231002-" + platform + "-install_only.tar.gz",
+			"requirements.txt",
+			"",
+		},
+		{
+			"mirror and lock file",
+			"    python:\n      version: 3.11.6\n      release: \"20231002\"\n      mirror: https://mirror.local/python/\n      requirements: requirements.txt\n      lock_file: requirements.lock\n",
+			true,
+			"https://mirror.local/python/20231002/cpython-3.11.6+20231002-" + platform + "-install_only.tar.gz",
+			"requirements.lock",
+			"",
+		},
+		{"no release", "    python:\n      version: 3.11.6\n", false, "", "", "requires a standalone build release"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wsconf := workspace(t, test.with)
+			v := new(plugin)
+			ok, err := v.Init(wsconf)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("Init err = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != test.ok {
+				t.Fatalf("Init = %v, want %v", ok, test.ok)
+			}
+			if !ok {
+				return
+			}
+			if v.installURL != test.url {
+				t.Errorf("install URL = %s, want %s", v.installURL, test.url)
+			}
+			if want := filepath.Join(wsconf.WorkingDir, test.requirements); v.requirementsPath != want {
+				t.Errorf("requirements = %s, want %s", v.requirementsPath, want)
+			}
+		})
+	}
+}
+
+func TestInitUnsupported(t *testing.T) {
+	key := runtime.GOOS + "/" + runtime.GOARCH
+	if platform, ok := pythonPlatforms[key]; ok {
+		delete(pythonPlatforms, key)
+		defer func() { pythonPlatforms[key] = platform }()
+	}
+	_, err := new(plugin).Init(workspace(t, "    python:\n      version: 3.11.6\n      release: \"20231002\"\n"))
+	if want := fmt.Sprintf("not supported on %s", key); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Init err = %v, want %q", err, want)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/bin")
+	v := new(plugin)
+	if _, err := v.Init(workspace(t, "    python:\n      version: 3.11.6\n      release: \"20231002\"\n")); err != nil {
+		t.Skip(err)
+	}
+	env := v.environment()
+	want := strings.Join([]string{
+		filepath.Join(v.venvPath, "bin"),
+		filepath.Join(v.installPath, "python", "bin"),
+		"/usr/bin:/bin",
+	}, ":")
+	if env["PATH"] != want {
+		t.Errorf("PATH = %s, want %s", env["PATH"], want)
+	}
+	if env["VIRTUAL_ENV"] != v.venvPath {
+		t.Errorf("VIRTUAL_ENV = %s, want %s", env["VIRTUAL_ENV"], v.venvPath)
+	}
+}
+
+func TestDetected(t *testing.T) {
+	if len(pythonPlatforms[runtime.GOOS+"/"+runtime.GOARCH]) == 0 {
+		t.Skipf("python is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	tests := []struct {
+		version string
+		err     bool
+	}{
+		{"3.11.6", false},
+		{"3.11", true},
+		{"3", true},
+	}
+	for _, test := range tests {
+		wsconf := workspace(t, "    python:\n      version: auto\n      release: \"20231002\"\n")
+		ioutil.WriteFile(filepath.Join(wsconf.WorkingDir, ".python-version"), []byte(test.version+"\n"), 0644)
+		v := new(plugin)
+		_, err := v.Init(wsconf)
+		if (err != nil) != test.err {
+			t.Errorf("Init(%s) err = %v, want error %v", test.version, err, test.err)
+		}
+		if err == nil && v.pyconf.Version != test.version {
+			t.Errorf("version = %s, want %s", v.pyconf.Version, test.version)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/mholt/archiver"
+	"github.com/samuelngs/dem/pkg/detect"
 	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/shim"
 	"github.com/samuelngs/dem/pkg/util/downloader"
 	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
 	"github.com/samuelngs/dem/pkg/workspaceconfig"
 	"gopkg.in/yaml.v2"
 )
@@ -20,19 +26,33 @@ import (
 //     program: /bin/zsh
 //   with:
 //     ruby:
-//       version: 2.5.3
+//       version: 2.5.3 # or auto to read .ruby-version
+//
+// Multiple versions are switched per directory by .ruby-version:
+//
+//   with:
+//     ruby:
+//       versions: [2.4.5, 2.5.3]
+//       default: 2.5.3
 
 var rubyBinaryHost = "https://s3.amazonaws.com/travis-rubies/binaries"
 
 type plugin struct {
 	wsconf       *workspaceconfig.Config
 	rubyconf     *rubyConfig
+	detected     *detect.Result
+	releases     []*release
+	installPath  string
+	releasesPath string
+	shim         *shim.Shim
+}
+
+type release struct {
+	version      string
 	binPath      string
 	refName      string
 	tarName      string
-	releasesPath string
 	installURL   string
-	installPath  string
 	downloadPath string
 }
 
@@ -49,7 +69,9 @@ type withConfig struct {
 }
 
 type rubyConfig struct {
-	Version string `yaml:"version"`
+	Version  string   `yaml:"version"`
+	Versions []string `yaml:"versions"`
+	Default  string   `yaml:"default"`
 }
 
 func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
@@ -57,54 +79,136 @@ func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
 	if err := yaml.Unmarshal(wsconf.Src, &rubyconf); err != nil {
 		return false, err
 	}
-	if rubyconf == nil || rubyconf.Workspace.With.Ruby == nil || len(rubyconf.Workspace.With.Ruby.Version) == 0 {
+	if rubyconf == nil || rubyconf.Workspace.With.Ruby == nil {
+		return false, nil
+	}
+	if len(rubyconf.Workspace.With.Ruby.Version) == 0 && len(rubyconf.Workspace.With.Ruby.Versions) == 0 {
 		return false, nil
 	}
 	v.wsconf = wsconf
 	v.rubyconf = rubyconf.Workspace.With.Ruby
-	v.refName = fmt.Sprintf("ruby-%s", v.rubyconf.Version)
-	v.tarName = fmt.Sprintf("%s.tar.bz2", v.refName)
+	if v.rubyconf.Version == detect.Auto {
+		detected, err := detect.Ruby(wsconf.WorkingDir)
+		if err != nil {
+			return false, err
+		}
+		// there is no release index to resolve partial versions against
+		if !resolver.IsExact(detected.Version) {
+			return false, fmt.Errorf("ruby version %s of %s is not exact", detected.Version, detected.Source)
+		}
+		v.detected = detected
+		v.rubyconf.Version = detected.Version
+	}
+	var platform string
 	switch runtime.GOOS {
 	case "darwin":
-		v.installURL = fmt.Sprintf("%s/osx/10.13/x86_64/%s", rubyBinaryHost, v.tarName)
+		platform = "osx/10.13/x86_64"
 	case "linux":
-		v.installURL = fmt.Sprintf("%s/ubuntu/16.04/x86_64/%s", rubyBinaryHost, v.tarName)
+		platform = "ubuntu/16.04/x86_64"
 	default:
 		return false, nil
 	}
+	versions := v.rubyconf.Versions
+	if len(versions) == 0 {
+		versions = []string{v.rubyconf.Version}
+	}
 	v.installPath = filepath.Join(v.wsconf.InstallationDir, "ruby")
 	v.releasesPath = filepath.Join(v.wsconf.InstallationDir, "ruby", "releases")
-	v.downloadPath = filepath.Join(v.releasesPath, v.tarName)
-	v.binPath = filepath.Join(v.installPath, v.refName, "bin", "ruby")
+	v.releases = make([]*release, len(versions))
+	for i, version := range versions {
+		r := &release{version: version}
+		r.refName = fmt.Sprintf("ruby-%s", version)
+		r.tarName = fmt.Sprintf("%s.tar.bz2", r.refName)
+		r.installURL = fmt.Sprintf("%s/%s/%s", rubyBinaryHost, platform, r.tarName)
+		r.downloadPath = filepath.Join(v.releasesPath, r.tarName)
+		r.binPath = filepath.Join(v.installPath, r.refName, "bin", "ruby")
+		v.releases[i] = r
+	}
+	v.rubyconf.Version = v.releases[len(v.releases)-1].version
+	if len(v.rubyconf.Default) > 0 {
+		v.rubyconf.Version = v.rubyconf.Default
+	}
+	if !v.installs(v.rubyconf.Version) {
+		return false, fmt.Errorf("default ruby version %s is not listed in versions", v.rubyconf.Version)
+	}
+	if len(v.releases) > 1 {
+		v.shim = &shim.Shim{
+			Name:     "ruby",
+			Dir:      filepath.Join(v.installPath, "shims"),
+			Commands: []string{"ruby", "gem", "irb", "bundle"},
+			Files: []shim.File{
+				{Name: ".ruby-version", Expression: `s/^\(ruby-\)\{0,1\}\([0-9][^ ]*\).*/\2/p`},
+				shim.ToolVersions("ruby"),
+			},
+			Versions: make(map[string]string),
+			Default:  v.rubyconf.Version,
+		}
+		for _, r := range v.releases {
+			v.shim.Versions[r.version] = filepath.Dir(r.binPath)
+		}
+	}
 	return true, nil
 }
 
+func (v *plugin) installs(version string) bool {
+	for _, r := range v.releases {
+		if r.version == version {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *plugin) SetupTasks() ext.SetupTasks {
-	if fs.Exists(v.binPath) {
-		return nil
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	for _, r := range v.releases {
+		r := r
+		suffix := ""
+		if len(v.releases) > 1 {
+			suffix = " " + r.version
+		}
+		path := filepath.Join(v.installPath, r.refName)
+		status, err := installation.Check(path, r.binPath)
+		if err != nil {
+			return ext.SetupTasks{ext.Failure("checking"+suffix, err)}
+		}
+		if status == ext.Installed {
+			continue
+		}
+		if status == ext.Adoptable {
+			tasks = append(tasks, ext.Adopt("recording"+suffix, path))
+			continue
+		}
+		tasks = append(tasks,
+			ext.Procedure("initializing"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				return fs.Mkdir(v.installPath, v.releasesPath)
+			}),
+			ext.Procedure("downloading"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				cb := make(chan int)
+				go func() {
+					var lp int
+					for progress := range cb {
+						bar.IncrBy(progress - lp)
+						lp = progress
+					}
+				}()
+				return downloader.New(r.installURL, r.downloadPath).Start(ctx, cb)
+			}),
+			ext.Procedure("unpacking"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+				return unpack.Unarchive(ctx, archiver.NewTarBz2(), r.downloadPath, v.installPath)
+			}, ext.Installs(path)),
+		)
 	}
-	return ext.SetupTasks{
-		ext.Procedure("initializing", func(bar ext.ProgressBar) error {
-			return fs.Mkdir(v.installPath, v.releasesPath)
-		}),
-		ext.Procedure("downloading", func(bar ext.ProgressBar) error {
-			cb := make(chan int)
-			go func() {
-				var lp int
-				for progress := range cb {
-					bar.IncrBy(progress - lp)
-					lp = progress
-				}
-			}()
-			return downloader.New(v.installURL, v.downloadPath).Start(cb)
-		}),
-		ext.Procedure("unpacking", func(bar ext.ProgressBar) error {
-			if err := archiver.NewTarBz2().Unarchive(v.downloadPath, v.installPath); err != nil {
-				return err
-			}
-			return nil
-		}),
+	if v.shim != nil && v.shim.Outdated() {
+		tasks = append(tasks, ext.Procedure("linking shims", func(ctx context.Context, bar ext.ProgressBar) error {
+			return v.shim.Write()
+		}))
 	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks
 }
 
 func (v *plugin) Environment() map[string]string {
@@ -120,10 +224,60 @@ func (v *plugin) Sources() []string {
 }
 
 func (v *plugin) Paths() []string {
-	return []string{filepath.Join(v.installPath, v.refName, "bin")}
+	if v.shim != nil {
+		return []string{v.shim.Dir}
+	}
+	return []string{filepath.Dir(v.releases[0].binPath)}
+}
+
+func (v *plugin) Owns() []string {
+	paths := make([]string, 0)
+	for _, r := range v.releases {
+		paths = append(paths, filepath.Join(v.installPath, r.refName), r.downloadPath)
+	}
+	if v.shim != nil {
+		paths = append(paths, v.shim.Dir)
+	}
+	return paths
+}
+
+func (v *plugin) Installation() *ext.Installation {
+	installation := &ext.Installation{Record: ext.StatePath(v.wsconf, "ruby")}
+	versions := make([]string, len(v.releases))
+	for i, r := range v.releases {
+		versions[i] = r.version
+		installation.Paths = append(installation.Paths, filepath.Join(v.installPath, r.refName))
+	}
+	installation.Version = strings.Join(versions, ", ")
+	return installation
+}
+
+func (v *plugin) Features() []*ext.Feature {
+	return []*ext.Feature{
+		{ID: "ghcr.io/devcontainers/features/ruby:1", Options: map[string]interface{}{"version": v.rubyconf.Version}},
+	}
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	return []string{"ruby", "gem"}
+}
+
+func (v *plugin) Detected() *detect.Result {
+	return v.detected
 }
 
 func (v *plugin) String() string {
+	if len(v.releases) > 1 {
+		versions := make([]string, len(v.releases))
+		for i, r := range v.releases {
+			versions[i] = r.version
+		}
+		return fmt.Sprintf("ruby %s", strings.Join(versions, ", "))
+	}
 	if v.rubyconf != nil && len(v.rubyconf.Version) > 0 {
 		return fmt.Sprintf("ruby %s", v.rubyconf.Version)
 	}
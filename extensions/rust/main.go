@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/samuelngs/dem/pkg/detect"
 	"github.com/samuelngs/dem/pkg/ext"
 	"github.com/samuelngs/dem/pkg/shell"
+	"github.com/samuelngs/dem/pkg/shim"
 	"github.com/samuelngs/dem/pkg/util/downloader"
 	"github.com/samuelngs/dem/pkg/util/envcomposer"
 	"github.com/samuelngs/dem/pkg/util/fs"
@@ -21,15 +26,39 @@ import (
 //     program: /bin/zsh
 //   with:
 //     rust:
-//       version: 1.29.2
+//       version: 1.29.2 # or auto to read rust-toolchain.toml
+//
+// Multiple toolchains are installed side by side and switched per directory
+// by rust-toolchain.toml, rust-toolchain or .tool-versions:
+//
+//   with:
+//     rust:
+//       versions: [1.70.0, stable]
+//       default: stable
+
+// commands of the toolchains which are shimmed
+var commands = []string{"cargo", "cargo-clippy", "cargo-fmt", "clippy-driver", "rustc", "rustdoc", "rustfmt", "rust-gdb", "rust-lldb"}
+
+// hosts are the rustup host triples of the platforms, toolchain directories
+// are suffixed with them
+var hosts = map[string]string{
+	"linux/amd64":  "x86_64-unknown-linux-gnu",
+	"linux/arm64":  "aarch64-unknown-linux-gnu",
+	"darwin/amd64": "x86_64-apple-darwin",
+	"darwin/arm64": "aarch64-apple-darwin",
+}
 
 type plugin struct {
 	wsconf            *workspaceconfig.Config
 	rsconf            *rustConfig
+	detected          *detect.Result
+	host              string
+	versions          []string
 	cargoPath         string
 	rustupPath        string
 	utlityPath        string
 	installScriptPath string
+	shim              *shim.Shim
 }
 
 type config struct {
@@ -45,7 +74,9 @@ type withConfig struct {
 }
 
 type rustConfig struct {
-	Version string `yaml:"version"`
+	Version  string   `yaml:"version"`
+	Versions []string `yaml:"versions"`
+	Default  string   `yaml:"default"`
 }
 
 func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
@@ -53,28 +84,148 @@ func (v *plugin) Init(wsconf *workspaceconfig.Config) (bool, error) {
 	if err := yaml.Unmarshal(wsconf.Src, &rustconf); err != nil {
 		return false, err
 	}
-	if rustconf == nil || rustconf.Workspace.With.Rust == nil || len(rustconf.Workspace.With.Rust.Version) == 0 {
+	if rustconf == nil || rustconf.Workspace.With.Rust == nil {
 		return false, nil
 	}
+	if len(rustconf.Workspace.With.Rust.Version) == 0 && len(rustconf.Workspace.With.Rust.Versions) == 0 {
+		return false, nil
+	}
+	host, ok := hosts[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok {
+		return false, fmt.Errorf("rust is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
 	v.wsconf = wsconf
 	v.rsconf = rustconf.Workspace.With.Rust
-	v.cargoPath = filepath.Join(v.wsconf.InstallationDir, "rust", v.rsconf.Version, ".cargo")
-	v.rustupPath = filepath.Join(v.wsconf.InstallationDir, "rust", v.rsconf.Version, ".multirust")
+	v.host = host
+	if v.rsconf.Version == detect.Auto {
+		detected, err := detect.Rust(wsconf.WorkingDir)
+		if err != nil {
+			return false, err
+		}
+		v.detected = detected
+		v.rsconf.Version = detected.Version
+	}
+	v.versions = v.rsconf.Versions
+	if len(v.versions) == 0 {
+		v.versions = []string{v.rsconf.Version}
+	}
+	v.rsconf.Version = v.versions[len(v.versions)-1]
+	if len(v.rsconf.Default) > 0 {
+		v.rsconf.Version = v.rsconf.Default
+	}
+	if !v.installs(v.rsconf.Version) {
+		return false, fmt.Errorf("default rust version %s is not listed in versions", v.rsconf.Version)
+	}
+	// rustup and cargo are shared by the toolchains, which are run by their
+	// paths instead of the rustup proxies
+	v.cargoPath = filepath.Join(v.wsconf.InstallationDir, "rust", ".cargo")
+	v.rustupPath = filepath.Join(v.wsconf.InstallationDir, "rust", ".rustup")
 	v.utlityPath = filepath.Join(v.wsconf.InstallationDir, "rust", "helper")
 	v.installScriptPath = filepath.Join(v.utlityPath, "rustup")
+	v.shim = nil
+	if len(v.versions) > 1 {
+		v.shim = &shim.Shim{
+			Name:     "rust",
+			Dir:      filepath.Join(v.wsconf.InstallationDir, "rust", "shims"),
+			Commands: commands,
+			Files: []shim.File{
+				{Name: "rust-toolchain.toml", Expression: `s/^channel *= *"\([^"]*\)".*/\1/p`},
+				{Name: "rust-toolchain", Expression: `s/^channel *= *"\([^"]*\)".*/\1/p`},
+				{Name: "rust-toolchain", Expression: `s/^\([0-9a-z][^ "=]*\)$/\1/p`},
+				shim.ToolVersions("rust"),
+			},
+			Versions: make(map[string]string),
+			Default:  v.rsconf.Version,
+		}
+		for _, version := range v.versions {
+			v.shim.Versions[version] = filepath.Join(v.toolchainPath(version), "bin")
+		}
+	}
 	return true, nil
 }
 
-func (v *plugin) SetupTasks() ext.SetupTasks {
+func (v *plugin) installs(version string) bool {
+	for _, s := range v.versions {
+		if s == version {
+			return true
+		}
+	}
+	return false
+}
+
+// toolchainPath returns the directory rustup installs the toolchain into
+func (v *plugin) toolchainPath(version string) string {
+	return filepath.Join(v.rustupPath, "toolchains", version+"-"+v.host)
+}
+
+func (v *plugin) run(ctx context.Context, command string, args ...string) error {
 	config := v.wsconf
-	if fs.Exists(v.cargoPath) && fs.Exists(v.rustupPath) {
+	envcomposer := envcomposer.New()
+	envcomposer.Set("CARGO_HOME", v.cargoPath)
+	envcomposer.Set("RUSTUP_HOME", v.rustupPath)
+	envcomposer.Set("SHELL", config.Workspace.Shell.Program)
+	envcomposer.Set("USER", config.Namespace)
+	envcomposer.Set("HOME", config.WorkingDir)
+	cmd := shell.New(command, args...)
+	cmd.SetDir(config.WorkingDir)
+	cmd.SetEnv(envcomposer.AsMap())
+	cmd.SetStdin(nil)
+	cmd.SetStdout(nil)
+	cmd.SetStderr(nil)
+	cmd.SetContext(ctx)
+	return cmd.Run()
+}
+
+func (v *plugin) SetupTasks() ext.SetupTasks {
+	tasks := make(ext.SetupTasks, 0)
+	installation := v.Installation()
+	rustup := filepath.Join(v.cargoPath, "bin", "rustup")
+	// cargo installs into its directory, rustup is not covered by checksums
+	if !fs.Exists(rustup) {
+		tasks = append(tasks, v.installTasks()...)
+	}
+	for _, version := range v.versions {
+		version := version
+		suffix := ""
+		if len(v.versions) > 1 {
+			suffix = " " + version
+		}
+		path := v.toolchainPath(version)
+		bin := filepath.Join(path, "bin", "rustc")
+		status, err := installation.Check(path, bin)
+		if err != nil {
+			return ext.SetupTasks{ext.Failure("checking"+suffix, err)}
+		}
+		if status == ext.Installed {
+			continue
+		}
+		if status == ext.Adoptable {
+			tasks = append(tasks, ext.Adopt("recording"+suffix, path))
+			continue
+		}
+		tasks = append(tasks, ext.Procedure("installing"+suffix, func(ctx context.Context, bar ext.ProgressBar) error {
+			return v.run(ctx, rustup, "toolchain", "install", version, "--profile", "default")
+		}, ext.Installs(path)))
+	}
+	if v.shim != nil && v.shim.Outdated() {
+		tasks = append(tasks, ext.Procedure("linking shims", func(ctx context.Context, bar ext.ProgressBar) error {
+			return v.shim.Write()
+		}))
+	}
+	if len(tasks) == 0 {
 		return nil
 	}
+	return tasks
+}
+
+// installTasks install rustup without a toolchain, toolchains are installed
+// by their own tasks
+func (v *plugin) installTasks() ext.SetupTasks {
 	return ext.SetupTasks{
-		ext.Procedure("initializing", func(bar ext.ProgressBar) error {
+		ext.Procedure("initializing", func(ctx context.Context, bar ext.ProgressBar) error {
 			return fs.Mkdir(v.utlityPath)
 		}),
-		ext.Procedure("downloading", func(bar ext.ProgressBar) error {
+		ext.Procedure("downloading", func(ctx context.Context, bar ext.ProgressBar) error {
 			cb := make(chan int)
 			go func() {
 				var lp int
@@ -85,25 +236,13 @@ func (v *plugin) SetupTasks() ext.SetupTasks {
 					}
 				}
 			}()
-			return downloader.New("https://sh.rustup.rs", v.installScriptPath).Start(cb)
+			return downloader.New("https://sh.rustup.rs", v.installScriptPath).Start(ctx, cb)
 		}),
-		ext.Procedure("installing", func(bar ext.ProgressBar) error {
+		ext.Procedure("installing rustup", func(ctx context.Context, bar ext.ProgressBar) error {
 			if err := os.Chmod(v.installScriptPath, 0755); err != nil {
 				return err
 			}
-			envcomposer := envcomposer.New()
-			envcomposer.Set("CARGO_HOME", v.cargoPath)
-			envcomposer.Set("RUSTUP_HOME", v.rustupPath)
-			envcomposer.Set("SHELL", config.Workspace.Shell.Program)
-			envcomposer.Set("USER", config.Namespace)
-			envcomposer.Set("HOME", config.WorkingDir)
-			cmd := shell.New(v.installScriptPath, "--no-modify-path", "--default-toolchain", v.rsconf.Version, "-y")
-			cmd.SetDir(config.WorkingDir)
-			cmd.SetEnv(envcomposer.AsMap())
-			cmd.SetStdin(nil)
-			cmd.SetStdout(nil)
-			cmd.SetStderr(nil)
-			return cmd.Run()
+			return v.run(ctx, v.installScriptPath, "--no-modify-path", "--default-host", v.host, "--default-toolchain", "none", "-y")
 		}),
 	}
 }
@@ -123,11 +262,61 @@ func (v *plugin) Sources() []string {
 	return nil
 }
 
+// Paths puts the toolchain before the rustup proxies of cargo, which also
+// contains the binaries installed by cargo install
 func (v *plugin) Paths() []string {
-	return []string{filepath.Join(v.cargoPath, "bin")}
+	if v.shim != nil {
+		return []string{v.shim.Dir, filepath.Join(v.cargoPath, "bin")}
+	}
+	return []string{filepath.Join(v.toolchainPath(v.rsconf.Version), "bin"), filepath.Join(v.cargoPath, "bin")}
+}
+
+func (v *plugin) Owns() []string {
+	paths := []string{v.cargoPath, v.utlityPath, filepath.Join(v.rustupPath, "settings.toml"), filepath.Join(v.rustupPath, "update-hashes")}
+	for _, version := range v.versions {
+		paths = append(paths, v.toolchainPath(version))
+	}
+	if v.shim != nil {
+		paths = append(paths, v.shim.Dir)
+	}
+	return paths
+}
+
+// Installation records the toolchains, components added by rustup change
+// their checksums
+func (v *plugin) Installation() *ext.Installation {
+	installation := &ext.Installation{
+		Record:  ext.StatePath(v.wsconf, "rust"),
+		Version: strings.Join(v.versions, ", "),
+	}
+	for _, version := range v.versions {
+		installation.Paths = append(installation.Paths, v.toolchainPath(version))
+	}
+	return installation
+}
+
+func (v *plugin) Features() []*ext.Feature {
+	return []*ext.Feature{
+		{ID: "ghcr.io/devcontainers/features/rust:1", Options: map[string]interface{}{"version": v.rsconf.Version}},
+	}
+}
+
+func (v *plugin) Requires() []string {
+	return nil
+}
+
+func (v *plugin) Provides() []string {
+	return []string{"rust", "cargo"}
+}
+
+func (v *plugin) Detected() *detect.Result {
+	return v.detected
 }
 
 func (v *plugin) String() string {
+	if len(v.versions) > 1 {
+		return fmt.Sprintf("rust %s", strings.Join(v.versions, ", "))
+	}
 	if v.rsconf != nil && len(v.rsconf.Version) > 0 {
 		return fmt.Sprintf("rust %s", v.rsconf.Version)
 	}
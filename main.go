@@ -4,14 +4,23 @@ import (
 	"os"
 	"strings"
 
+	"github.com/samuelngs/dem/cmd/allow"
 	"github.com/samuelngs/dem/cmd/create"
 	"github.com/samuelngs/dem/cmd/delete"
+	"github.com/samuelngs/dem/cmd/deny"
 	"github.com/samuelngs/dem/cmd/describe"
+	"github.com/samuelngs/dem/cmd/doctor"
+	"github.com/samuelngs/dem/cmd/export"
+	"github.com/samuelngs/dem/cmd/gc"
+	"github.com/samuelngs/dem/cmd/hook"
 	"github.com/samuelngs/dem/cmd/list"
+	"github.com/samuelngs/dem/cmd/plugin"
 	"github.com/samuelngs/dem/cmd/shell"
 	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/util/exec"
 	"github.com/samuelngs/dem/pkg/util/fs"
 	"github.com/samuelngs/dem/pkg/util/homedir"
+	"github.com/samuelngs/dem/pkg/util/netns"
 	"github.com/spf13/cobra"
 )
 
@@ -54,10 +63,17 @@ func NewCommand() *cobra.Command {
 	})
 
 	// workspace available comments
+	cmd.AddCommand(allow.NewCommand())
 	cmd.AddCommand(create.NewCommand())
 	cmd.AddCommand(delete.NewCommand())
+	cmd.AddCommand(deny.NewCommand())
 	cmd.AddCommand(describe.NewCommand())
+	cmd.AddCommand(doctor.NewCommand())
+	cmd.AddCommand(export.NewCommand())
+	cmd.AddCommand(gc.NewCommand())
+	cmd.AddCommand(hook.NewCommand())
 	cmd.AddCommand(list.NewCommand())
+	cmd.AddCommand(plugin.NewCommand())
 
 	// too magical for this crap
 	if args := os.Args[1:]; len(args) > 0 {
@@ -76,8 +92,16 @@ func Run() error {
 }
 
 func main() {
+	// dem re-executes itself inside of an isolated network namespace
+	if netns.IsHelper() {
+		os.Exit(netns.RunHelper())
+	}
 	if err := Run(); err != nil {
+		// propagate the exit status of the workspace shell or editor
+		if code, ok := exec.ExitStatus(err); ok {
+			os.Exit(code)
+		}
 		os.Stderr.WriteString(err.Error() + "\n")
-		os.Exit(-1)
+		os.Exit(1)
 	}
 }
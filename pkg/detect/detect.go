@@ -0,0 +1,159 @@
+package detect
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Auto is the version value which enables detection
+const Auto = "auto"
+
+// Result is a version detected from a project file
+type Result struct {
+	Version string
+	Source  string
+}
+
+// detector reads the version from a single file, returns an empty string if
+// the file does not define a version
+type detector struct {
+	file string
+	read func([]byte) string
+}
+
+var (
+	goDirective        = regexp.MustCompile(`(?m)^go\s+(\S+)`)
+	goToolchain        = regexp.MustCompile(`(?m)^toolchain\s+go(\S+)`)
+	rustToolchainToml  = regexp.MustCompile(`(?m)^\s*channel\s*=\s*"([^"]+)"`)
+	nodeEngineOperator = regexp.MustCompile(`^>=?\s*v?`)
+)
+
+func firstLine(b []byte) string {
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+func submatch(re *regexp.Regexp) func([]byte) string {
+	return func(b []byte) string {
+		if m := re.FindSubmatch(b); m != nil {
+			return string(m[1])
+		}
+		return ""
+	}
+}
+
+// toolVersions reads the version of a tool from an asdf .tool-versions file
+func toolVersions(tool string) detector {
+	return detector{".tool-versions", func(b []byte) string {
+		scanner := bufio.NewScanner(strings.NewReader(string(b)))
+		for scanner.Scan() {
+			if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == tool {
+				return fields[1]
+			}
+		}
+		return ""
+	}}
+}
+
+func detect(name, dir string, detectors ...detector) (*Result, error) {
+	for _, d := range detectors {
+		b, err := ioutil.ReadFile(filepath.Join(dir, d.file))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if version := d.read(b); len(version) > 0 {
+			return &Result{Version: version, Source: d.file}, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to detect %s version in %s", name, dir)
+}
+
+// Go detects the go version from go.mod (toolchain line first), .go-version
+// or .tool-versions
+func Go(dir string) (*Result, error) {
+	return detect("go", dir,
+		detector{"go.mod", submatch(goToolchain)},
+		detector{"go.mod", submatch(goDirective)},
+		detector{".go-version", firstLine},
+		toolVersions("golang"),
+	)
+}
+
+// Node detects the node version from .nvmrc, .node-version, .tool-versions
+// or the engines field of package.json
+func Node(dir string) (*Result, error) {
+	nodeVersion := func(b []byte) string {
+		s := strings.TrimPrefix(firstLine(b), "v")
+		if strings.HasPrefix(s, "lts/") {
+			return "lts"
+		}
+		return s
+	}
+	return detect("node", dir,
+		detector{".nvmrc", nodeVersion},
+		detector{".node-version", nodeVersion},
+		toolVersions("nodejs"),
+		detector{"package.json", func(b []byte) string {
+			var pkg struct {
+				Engines struct {
+					Node string `json:"node"`
+				} `json:"engines"`
+			}
+			if err := json.Unmarshal(b, &pkg); err != nil {
+				return ""
+			}
+			// a lower bound (e.g >=18) is treated as the same major version
+			s := strings.TrimSpace(pkg.Engines.Node)
+			if nodeEngineOperator.MatchString(s) {
+				return "^" + nodeEngineOperator.ReplaceAllString(s, "")
+			}
+			return strings.TrimPrefix(s, "v")
+		}},
+	)
+}
+
+// Ruby detects the ruby version from .ruby-version or .tool-versions
+func Ruby(dir string) (*Result, error) {
+	return detect("ruby", dir,
+		detector{".ruby-version", func(b []byte) string {
+			return strings.TrimPrefix(firstLine(b), "ruby-")
+		}},
+		toolVersions("ruby"),
+	)
+}
+
+// Rust detects the rust toolchain from rust-toolchain.toml, rust-toolchain
+// or .tool-versions
+func Rust(dir string) (*Result, error) {
+	return detect("rust", dir,
+		detector{"rust-toolchain.toml", submatch(rustToolchainToml)},
+		detector{"rust-toolchain", func(b []byte) string {
+			if m := rustToolchainToml.FindSubmatch(b); m != nil {
+				return string(m[1])
+			}
+			return firstLine(b)
+		}},
+		toolVersions("rust"),
+	)
+}
+
+// Python detects the python version from .python-version or .tool-versions
+func Python(dir string) (*Result, error) {
+	return detect("python", dir,
+		detector{".python-version", firstLine},
+		toolVersions("python"),
+	)
+}
@@ -0,0 +1,62 @@
+package detect
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		detect func(string) (*Result, error)
+		files  map[string]string
+		want   *Result
+	}{
+		{"go directive", Go, map[string]string{"go.mod": "module example.com/m\n\ngo 1.22\n"}, &Result{"1.22", "go.mod"}},
+		{"go toolchain", Go, map[string]string{"go.mod": "module example.com/m\n\ngo 1.21\ntoolchain go1.21.3\n"}, &Result{"1.21.3", "go.mod"}},
+		{"go-version", Go, map[string]string{".go-version": "# pinned\n1.20.10\n"}, &Result{"1.20.10", ".go-version"}},
+		{"go tool-versions", Go, map[string]string{".tool-versions": "nodejs 18.18.2\ngolang 1.21.3\n"}, &Result{"1.21.3", ".tool-versions"}},
+		{"go.mod first", Go, map[string]string{"go.mod": "go 1.22\n", ".go-version": "1.20.10\n"}, &Result{"1.22", "go.mod"}},
+		{"go.mod without version", Go, map[string]string{"go.mod": "module example.com/m\n", ".go-version": "1.20.10\n"}, &Result{"1.20.10", ".go-version"}},
+		{"nvmrc", Node, map[string]string{".nvmrc": "v18\n"}, &Result{"18", ".nvmrc"}},
+		{"nvmrc lts", Node, map[string]string{".nvmrc": "lts/hydrogen\n"}, &Result{"lts", ".nvmrc"}},
+		{"node-version", Node, map[string]string{".node-version": "20.9.0\n"}, &Result{"20.9.0", ".node-version"}},
+		{"node tool-versions", Node, map[string]string{".tool-versions": "nodejs 18.18.2\n"}, &Result{"18.18.2", ".tool-versions"}},
+		{"engines lower bound", Node, map[string]string{"package.json": `{"engines": {"node": ">=18"}}`}, &Result{"^18", "package.json"}},
+		{"engines range", Node, map[string]string{"package.json": `{"engines": {"node": "^20.9"}}`}, &Result{"^20.9", "package.json"}},
+		{"engines exact", Node, map[string]string{"package.json": `{"engines": {"node": "v18.18.2"}}`}, &Result{"18.18.2", "package.json"}},
+		{"ruby-version", Ruby, map[string]string{".ruby-version": "ruby-3.2.2\n"}, &Result{"3.2.2", ".ruby-version"}},
+		{"ruby tool-versions", Ruby, map[string]string{".tool-versions": "ruby 3.1.4\n"}, &Result{"3.1.4", ".tool-versions"}},
+		{"rust-toolchain.toml", Rust, map[string]string{"rust-toolchain.toml": "[toolchain]\nchannel = \"1.74.0\"\n"}, &Result{"1.74.0", "rust-toolchain.toml"}},
+		{"rust-toolchain legacy", Rust, map[string]string{"rust-toolchain": "nightly-2023-11-01\n"}, &Result{"nightly-2023-11-01", "rust-toolchain"}},
+		{"rust-toolchain toml syntax", Rust, map[string]string{"rust-toolchain": "[toolchain]\nchannel = \"stable\"\n"}, &Result{"stable", "rust-toolchain"}},
+		{"python-version", Python, map[string]string{".python-version": "3.11.6\n"}, &Result{"3.11.6", ".python-version"}},
+		{"python tool-versions", Python, map[string]string{".tool-versions": "python 3.12.0\n"}, &Result{"3.12.0", ".tool-versions"}},
+		{"not found", Python, map[string]string{".tool-versions": "nodejs 18.18.2\n"}, nil},
+		{"no files", Go, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, body := range test.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := test.detect(dir)
+			if test.want == nil {
+				if err == nil {
+					t.Fatalf("detected %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != *test.want {
+				t.Errorf("detected %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Package devcontainer writes devcontainer.json configurations
+// (https://containers.dev/implementors/json_reference)
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/samuelngs/dem/pkg/util/fs"
+)
+
+// DefaultImage is the image of devcontainers, toolchains are installed by
+// features on top of it
+const DefaultImage = "mcr.microsoft.com/devcontainers/base:ubuntu"
+
+// Config is a devcontainer.json configuration
+type Config struct {
+	Name           string                            `json:"name"`
+	Image          string                            `json:"image"`
+	Features       map[string]map[string]interface{} `json:"features,omitempty"`
+	ContainerEnv   map[string]string                 `json:"containerEnv,omitempty"`
+	ForwardPorts   []int                             `json:"forwardPorts,omitempty"`
+	Customizations map[string]interface{}            `json:"customizations,omitempty"`
+}
+
+// Path returns the path of the devcontainer.json of a project directory
+func Path(dir string) string {
+	return filepath.Join(dir, ".devcontainer", "devcontainer.json")
+}
+
+// Write writes the configuration into the project directory, an existing
+// configuration is only replaced if overwrite is set
+func (v *Config) Write(dir string, overwrite bool) (string, error) {
+	path := Path(dir)
+	if fs.Exists(path) && !overwrite {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := fs.Mkdir(filepath.Dir(path)); err != nil {
+		return "", err
+	}
+	return path, fs.WriteFile(path, append(b, '\n'))
+}
@@ -0,0 +1,243 @@
+package doctor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/globalconfig"
+	"github.com/samuelngs/dem/pkg/registry"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// Reserved are the environment variables set by dem for the workspace shell,
+// overriding them in the workspace configuration breaks the session
+var Reserved = []string{"HOME", "USER", "SHELL", "CWKS"}
+
+// Global checks the global configuration, the storage and plugins directories
+// and the installed extension modules
+func Global(configPath string) *Report {
+	report := new(Report)
+	report.config(configPath)
+	report.dir("storage_dir", os.ExpandEnv(globalconfig.Settings.StorageDir))
+	report.dir("plugins_dir", os.ExpandEnv(globalconfig.Settings.PluginsDir))
+	report.plugins(os.ExpandEnv(globalconfig.Settings.PluginsDir))
+	return report
+}
+
+func (v *Report) config(path string) {
+	if !fs.Exists(path) {
+		v.pass("config", fmt.Sprintf("%s not found, using defaults", path))
+		return
+	}
+	if err := globalconfig.Load(path); err != nil {
+		v.fail("config", fmt.Sprintf("unable to parse %s, %v", path, err), "fix the YAML syntax of the config file")
+		return
+	}
+	v.pass("config", path)
+}
+
+// dir checks that the directory exists and is writable
+func (v *Report) dir(name, path string) {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		v.warn(name, fmt.Sprintf("%s does not exist", path), "it is created on the next run of dem")
+		return
+	case err != nil:
+		v.fail(name, err.Error(), fmt.Sprintf("check the permissions of the parent directory of %s", path))
+		return
+	case !info.IsDir():
+		v.fail(name, fmt.Sprintf("%s is not a directory", path), fmt.Sprintf("remove %s or change %s in the config file", path, name))
+		return
+	}
+	f, err := ioutil.TempFile(path, ".doctor-")
+	if err != nil {
+		v.fail(name, fmt.Sprintf("%s is not writable", path), fmt.Sprintf("run `chmod u+rwx %s`", path))
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	v.pass(name, path)
+}
+
+func (v *Report) plugins(pluginsDir string) {
+	modules, errs, err := ext.Modules(pluginsDir)
+	if err != nil {
+		v.fail("plugins", err.Error(), "")
+		return
+	}
+	versions := make(map[string]string)
+	if reg, err := registry.Load(pluginsDir); err == nil {
+		for name, entry := range reg.Plugins {
+			versions[name] = entry.Version
+		}
+	}
+	for _, m := range modules {
+		message := "loaded"
+		if version, ok := versions[m.Name]; ok {
+			message = fmt.Sprintf("loaded, version %s", version)
+		}
+		v.pass("plugin "+m.Name, message)
+	}
+	for _, err := range errs {
+		name := strings.TrimSuffix(err.Module, ".so")
+		hint := fmt.Sprintf("reinstall it with `dem plugin install %s`", name)
+		// modules must be built by the same go toolchain and dependencies
+		if strings.Contains(err.Err.Error(), "different version") {
+			hint = fmt.Sprintf("rebuild it with %s and the dependencies of this dem build, or run `dem plugin update %s`", runtime.Version(), name)
+		}
+		v.fail("plugin "+name, err.Err.Error(), hint)
+	}
+}
+
+// Workspace checks the configuration, shell and extensions of a workspace
+func Workspace(report *Report, namespace, storageDir, pluginsDir string) {
+	report.Namespace = namespace
+	config, err := workspaceconfig.Open(namespace, storageDir, pluginsDir)
+	if err != nil {
+		report.fail("workspace", err.Error(), fmt.Sprintf("run `dem %s edit` to fix the configuration", namespace))
+		return
+	}
+	report.pass("workspace", config.ConfigPath)
+	report.shell(config)
+	report.dotfiles(filepath.Join(config.WorkingDir, ".workspace_shell"))
+
+	exts, diagnostics, err := ext.Load(config)
+	if err != nil {
+		report.fail("extensions", err.Error(), "")
+		return
+	}
+	for _, err := range diagnostics.Errors {
+		report.fail("extension "+strings.TrimSuffix(err.Module, ".so"), err.Err.Error(), "fix the extension configuration in the `with` section")
+	}
+	for _, key := range diagnostics.Unknown {
+		report.fail("extension "+key, "unknown extension", fmt.Sprintf("run `dem plugin install %s` or remove it from the `with` section", key))
+	}
+	for _, extension := range exts {
+		report.extension(namespace, extension)
+	}
+	report.environment(config, exts)
+}
+
+func (v *Report) shell(config *workspaceconfig.Config) {
+	program := config.Workspace.Shell.Program
+	path, err := exec.LookPath(program)
+	if err != nil {
+		v.fail("shell", fmt.Sprintf("%s not found", program), "install it or change workspace.shell.program")
+		return
+	}
+	v.pass("shell", path)
+}
+
+// dotfiles checks the symbolic links to the workspace dotfiles created for
+// the shell
+func (v *Report) dotfiles(dotdir string) {
+	files, err := ioutil.ReadDir(dotdir)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		v.fail("dotfiles", err.Error(), "")
+		return
+	}
+	broken := 0
+	for _, file := range files {
+		path := filepath.Join(dotdir, file.Name())
+		if file.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			target, _ := os.Readlink(path)
+			v.warn("dotfiles", fmt.Sprintf("%s points to missing %s", path, target), fmt.Sprintf("remove %s, it is recreated when the workspace starts", path))
+			broken++
+		}
+	}
+	if broken == 0 {
+		v.pass("dotfiles", dotdir)
+	}
+}
+
+func (v *Report) extension(namespace string, extension ext.Extension) {
+	name := "extension " + extension.String()
+	if r, ok := extension.(ext.Resolver); ok && !r.Resolved() {
+		v.warn(name, "versions are not resolved", fmt.Sprintf("start the workspace with `dem %s` to resolve and install them", namespace))
+		return
+	}
+	if tasks := extension.SetupTasks(); len(tasks) > 0 {
+		v.warn(name, "not set up", fmt.Sprintf("start the workspace with `dem %s` to install it", namespace))
+		return
+	}
+	// a directory of the PATH without executables (e.g an empty bin after an
+	// interrupted installation) provides no commands
+	for _, path := range extension.Paths() {
+		if !hasExecutables(path) {
+			v.fail(name, fmt.Sprintf("%s contains no executables", path), fmt.Sprintf("run `dem %s repair`", namespace))
+			return
+		}
+	}
+	if installer, ok := extension.(ext.Installer); ok {
+		problems, err := installer.Installation().Verify()
+		if err != nil {
+			v.fail(name, err.Error(), "")
+			return
+		}
+		if len(problems) > 0 {
+			messages := make([]string, len(problems))
+			for i, problem := range problems {
+				messages[i] = problem.String()
+			}
+			v.fail(name, strings.Join(messages, ", "), fmt.Sprintf("run `dem %s repair`", namespace))
+			return
+		}
+	}
+	v.pass(name, "installed")
+}
+
+// hasExecutables reports whether the directory contains an executable file,
+// symbolic links are followed
+func hasExecutables(dir string) bool {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		info, err := os.Stat(filepath.Join(dir, file.Name()))
+		if err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// environment checks that the workspace and extension environment variables
+// do not override the ones reserved by dem
+func (v *Report) environment(config *workspaceconfig.Config, exts []ext.Extension) {
+	sources := make(map[string][]string)
+	for key := range config.Workspace.Environment {
+		sources[key] = append(sources[key], "workspace.environment")
+	}
+	for _, extension := range exts {
+		for key := range extension.Environment() {
+			sources[key] = append(sources[key], extension.String())
+		}
+	}
+	conflicts := make([]string, 0)
+	for _, key := range Reserved {
+		if from, ok := sources[key]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", key, strings.Join(from, ", ")))
+		}
+	}
+	sort.Strings(conflicts)
+	if len(conflicts) > 0 {
+		v.warn("environment", fmt.Sprintf("reserved variables are overridden: %s", strings.Join(conflicts, ", ")), "remove them from the environment of the workspace")
+		return
+	}
+	v.pass("environment", "no reserved variables are overridden")
+}
@@ -0,0 +1,69 @@
+package doctor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+type fake struct {
+	paths    []string
+	resolved bool
+	tasks    ext.SetupTasks
+}
+
+func (v *fake) Init(*workspaceconfig.Config) (bool, error) { return true, nil }
+func (v *fake) SetupTasks() ext.SetupTasks                 { return v.tasks }
+func (v *fake) Environment() map[string]string             { return nil }
+func (v *fake) Aliases() map[string]string                 { return nil }
+func (v *fake) Sources() []string                          { return nil }
+func (v *fake) Paths() []string                            { return v.paths }
+func (v *fake) String() string                             { return "fake" }
+func (v *fake) Resolved() bool                             { return v.resolved }
+
+func (v *fake) Resolve() error {
+	panic("doctor resolved versions")
+}
+
+func TestExtension(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty", "bin")
+	os.MkdirAll(empty, 0755)
+	files := filepath.Join(dir, "files", "bin")
+	os.MkdirAll(files, 0755)
+	ioutil.WriteFile(filepath.Join(files, "README"), nil, 0644)
+	bin := filepath.Join(dir, "tool", "bin")
+	os.MkdirAll(bin, 0755)
+	ioutil.WriteFile(filepath.Join(bin, "tool"), []byte("#!/bin/sh\n"), 0755)
+	os.Symlink(filepath.Join(bin, "tool"), filepath.Join(dir, "tool", "link"))
+
+	tests := []struct {
+		name      string
+		extension *fake
+		status    Status
+	}{
+		{"unresolved", &fake{}, Warn},
+		{"not set up", &fake{resolved: true, tasks: ext.SetupTasks{nil}}, Warn},
+		{"missing", &fake{resolved: true, paths: []string{filepath.Join(dir, "missing")}}, Fail},
+		{"empty", &fake{resolved: true, paths: []string{empty}}, Fail},
+		{"not executable", &fake{resolved: true, paths: []string{files}}, Fail},
+		{"installed", &fake{resolved: true, paths: []string{bin}}, Pass},
+		{"symlink", &fake{resolved: true, paths: []string{filepath.Join(dir, "tool")}}, Pass},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := new(Report)
+			report.extension("test", test.extension)
+			if len(report.Checks) != 1 {
+				t.Fatalf("checks = %v, want 1", report.Checks)
+			}
+			if check := report.Checks[0]; check.Status != test.status {
+				t.Errorf("status = %s (%s), want %s", check.Status, check.Message, test.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+)
+
+// Status of a check
+type Status string
+
+// Check statuses
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Check is the result of a single health check
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+	// remediation of a failed check
+	Hint string `json:"hint,omitempty"`
+}
+
+// Report is the list of checks of dem and optionally a workspace
+type Report struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Checks    []*Check `json:"checks"`
+}
+
+func (v *Report) pass(name, message string) {
+	v.Checks = append(v.Checks, &Check{name, Pass, message, ""})
+}
+
+func (v *Report) warn(name, message, hint string) {
+	v.Checks = append(v.Checks, &Check{name, Warn, message, hint})
+}
+
+func (v *Report) fail(name, message, hint string) {
+	v.Checks = append(v.Checks, &Check{name, Fail, message, hint})
+}
+
+// Failed reports whether any of the checks failed
+func (v *Report) Failed() bool {
+	for _, check := range v.Checks {
+		if check.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the report in a human readable format
+func (v *Report) Print(w io.Writer) {
+	counts := make(map[Status]int)
+	for _, check := range v.Checks {
+		counts[check.Status]++
+		fmt.Fprintf(w, "[%s] %s", check.Status, check.Name)
+		if len(check.Message) > 0 {
+			fmt.Fprintf(w, ": %s", check.Message)
+		}
+		fmt.Fprintln(w)
+		if len(check.Hint) > 0 {
+			fmt.Fprintf(w, "       %s\n", check.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", counts[Pass], counts[Warn], counts[Fail])
+}
@@ -0,0 +1,56 @@
+package ext
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LoadError is an extension module which failed to open or initialize
+type LoadError struct {
+	Module string
+	Err    error
+}
+
+func (v *LoadError) Error() string {
+	return fmt.Sprintf("%s: %v", v.Module, v.Err)
+}
+
+// Diagnostics are the problems found while loading the extensions of a
+// workspace
+type Diagnostics struct {
+	Errors []*LoadError
+	// keys of the `with` section which no installed extension claims
+	Unknown []string
+}
+
+// Empty reports whether all extensions loaded without problems
+func (v *Diagnostics) Empty() bool {
+	return len(v.Errors) == 0 && len(v.Unknown) == 0
+}
+
+func (v *Diagnostics) messages() []string {
+	messages := make([]string, 0, len(v.Errors)+len(v.Unknown))
+	for _, err := range v.Errors {
+		messages = append(messages, err.Error())
+	}
+	for _, key := range v.Unknown {
+		messages = append(messages, fmt.Sprintf("unknown extension '%s' (run `dem plugin install %s` if it is not installed)", key, key))
+	}
+	return messages
+}
+
+// Print writes the diagnostics as warnings
+func (v *Diagnostics) Print(w io.Writer) {
+	for _, message := range v.messages() {
+		fmt.Fprintf(w, "warning: %s\n", message)
+	}
+}
+
+// Err returns the diagnostics as a single error, nil if there are none
+func (v *Diagnostics) Err() error {
+	if v.Empty() {
+		return nil
+	}
+	return fmt.Errorf("extensions failed to load:\n  %s", strings.Join(v.messages(), "\n  "))
+}
@@ -1,6 +1,8 @@
 package ext
 
 import (
+	"github.com/samuelngs/dem/pkg/detect"
+	"github.com/samuelngs/dem/pkg/resolver"
 	"github.com/samuelngs/dem/pkg/workspaceconfig"
 )
 
@@ -14,3 +16,51 @@ type Extension interface {
 	Paths() []string
 	String() string
 }
+
+// Dependent is implemented by extensions which provide capabilities (e.g the
+// commands they install) or require the ones of other extensions. Extensions
+// are set up after the extensions providing their requirements.
+type Dependent interface {
+	Requires() []string
+	Provides() []string
+}
+
+// Detector is implemented by extensions which infer their version from
+// project files in the working directory (`version: auto`)
+type Detector interface {
+	Detected() *detect.Result
+}
+
+// Owner is implemented by extensions which install into the workspace, the
+// paths (installations and downloaded archives) used by the configuration are
+// kept by garbage collection and everything else is removed
+type Owner interface {
+	Owns() []string
+}
+
+// Resolver is implemented by extensions which resolve their versions against
+// an upstream release index. Init only uses exact and locked versions, the
+// others are resolved by Resolve, which Setup calls before the setup tasks.
+type Resolver interface {
+	Resolved() bool
+	Resolve() error
+}
+
+// Upgrader is implemented by extensions which resolve their version from an
+// upstream release index. It returns nil if the version cannot be upgraded
+// (e.g it is detected from project files).
+type Upgrader interface {
+	Upgrade(policy resolver.Policy) (*resolver.Upgrade, error)
+}
+
+// Feature is a devcontainer feature (https://containers.dev/features)
+type Feature struct {
+	ID      string
+	Options map[string]interface{}
+}
+
+// Featurer is implemented by extensions which have equivalent devcontainer
+// features, they are used when the workspace is exported as devcontainer
+type Featurer interface {
+	Features() []*Feature
+}
@@ -0,0 +1,97 @@
+package ext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provides returns the capabilities provided by the extension, none if it is
+// not a Dependent
+func Provides(extension Extension) []string {
+	if dependent, ok := extension.(Dependent); ok {
+		return dependent.Provides()
+	}
+	return nil
+}
+
+// Requires returns the capabilities required by the extension, none if it is
+// not a Dependent
+func Requires(extension Extension) []string {
+	if dependent, ok := extension.(Dependent); ok {
+		return dependent.Requires()
+	}
+	return nil
+}
+
+// dependencies maps every extension to the extensions providing its
+// requirements, a capability provided by more than one extension is an error
+func dependencies(extensions []Extension) (map[Extension][]Extension, error) {
+	providers := make(map[string]Extension)
+	for _, extension := range extensions {
+		for _, capability := range Provides(extension) {
+			if provider, ok := providers[capability]; ok && provider != extension {
+				return nil, fmt.Errorf("'%s' is provided by both extension '%s' and '%s'", capability, provider, extension)
+			}
+			providers[capability] = extension
+		}
+	}
+	deps := make(map[Extension][]Extension)
+	for _, extension := range extensions {
+		deps[extension] = make([]Extension, 0)
+		for _, capability := range Requires(extension) {
+			provider, ok := providers[capability]
+			if !ok {
+				return nil, fmt.Errorf("extension '%s' requires '%s' which is not provided by any enabled extension", extension, capability)
+			}
+			if provider != extension {
+				deps[extension] = append(deps[extension], provider)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// Sort orders extensions so that every extension comes after the extensions
+// it requires, independent extensions keep their order (Load orders them by
+// module key). The order is used for setup tasks and PATH precedence.
+func Sort(extensions []Extension) ([]Extension, error) {
+	deps, err := dependencies(extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make([]Extension, len(extensions))
+	copy(remaining, extensions)
+
+	var (
+		ordered = make([]Extension, 0, len(extensions))
+		placed  = make(map[Extension]bool)
+	)
+	for len(remaining) > 0 {
+		next := -1
+		for i, extension := range remaining {
+			ready := true
+			for _, dep := range deps[extension] {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			names := make([]string, len(remaining))
+			for i, extension := range remaining {
+				names[i] = extension.String()
+			}
+			return nil, fmt.Errorf("dependency cycle between extensions: %s", strings.Join(names, ", "))
+		}
+		placed[remaining[next]] = true
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return ordered, nil
+}
@@ -0,0 +1,62 @@
+package ext
+
+import (
+	"strings"
+	"testing"
+)
+
+// dependent is an extension providing and requiring capabilities
+type dependent struct {
+	fake
+	requires, provides []string
+}
+
+func (v *dependent) Requires() []string { return v.requires }
+func (v *dependent) Provides() []string { return v.provides }
+
+func TestSort(t *testing.T) {
+	var (
+		golang  = &dependent{fake: fake{name: "go 1.20"}, provides: []string{"go"}}
+		node    = &dependent{fake: fake{name: "node 18"}, provides: []string{"node", "npm"}}
+		tools   = &dependent{fake: fake{name: "binaries"}, requires: []string{"go", "npm"}, provides: []string{"gotools"}}
+		other   = &dependent{fake: fake{name: "other"}, provides: []string{"go"}}
+		plain   = &fake{name: "plain"}
+		cycleA  = &dependent{fake: fake{name: "a"}, requires: []string{"b"}, provides: []string{"a"}}
+		cycleB  = &dependent{fake: fake{name: "b"}, requires: []string{"a"}, provides: []string{"b"}}
+		missing = &dependent{fake: fake{name: "missing"}, requires: []string{"ruby"}}
+	)
+	tests := []struct {
+		name       string
+		extensions []Extension
+		want       []Extension
+		err        string
+	}{
+		{"keeps order", []Extension{node, plain, golang}, []Extension{node, plain, golang}, ""},
+		{"requirements first", []Extension{tools, node, golang}, []Extension{node, golang, tools}, ""},
+		{"duplicate provider", []Extension{golang, other}, nil, "'go' is provided by both extension 'go 1.20' and 'other'"},
+		{"cycle", []Extension{cycleA, cycleB}, nil, "dependency cycle between extensions: a, b"},
+		{"missing", []Extension{missing}, nil, "requires 'ruby'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Sort(test.extensions)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("err = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package ext
+
+import (
+	"sort"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// Load opens the extension modules in the plugins directory and initializes
+// the ones enabled by the workspace configuration. Modules which fail to open
+// or initialize and unknown keys of the `with` section are returned as
+// diagnostics, the remaining extensions are still loaded.
+func Load(config *workspaceconfig.Config) ([]Extension, *Diagnostics, error) {
+	var (
+		extensions  = make([]Extension, 0)
+		diagnostics = new(Diagnostics)
+	)
+	if config.Workspace.With == nil {
+		return extensions, diagnostics, nil
+	}
+	modules, errs, err := Modules(config.PluginsDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	diagnostics.Errors = errs
+	// extensions are ordered by module key, the version in their names does
+	// not change the order
+	sort.SliceStable(modules, func(i, j int) bool {
+		return modules[i].Key < modules[j].Key
+	})
+	claimed := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		claimed[m.Key] = true
+		success, err := m.Extension.Init(config)
+		if err != nil {
+			diagnostics.Errors = append(diagnostics.Errors, &LoadError{m.Name, err})
+			continue
+		}
+		if success {
+			extensions = append(extensions, m.Extension)
+		}
+	}
+	for key := range config.Workspace.With {
+		if !claimed[key] {
+			diagnostics.Unknown = append(diagnostics.Unknown, key)
+		}
+	}
+	sort.Strings(diagnostics.Unknown)
+	extensions, err = Sort(extensions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return extensions, diagnostics, nil
+}
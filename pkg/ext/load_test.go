@@ -0,0 +1,78 @@
+package ext
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		with    map[string]interface{}
+		modules []string
+		errors  []string
+		unknown []string
+	}{
+		{"no extensions", nil, []string{"broken.so"}, nil, nil},
+		{"broken module", map[string]interface{}{}, []string{"broken.so", "README"}, []string{"broken.so"}, nil},
+		{"unknown extension", map[string]interface{}{"pyhton": nil, "go": nil}, nil, nil, []string{"go", "pyhton"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pluginsDir := t.TempDir()
+			for _, name := range test.modules {
+				ioutil.WriteFile(filepath.Join(pluginsDir, name), []byte("not a go plugin"), 0644)
+			}
+			config := &workspaceconfig.Config{
+				PluginsDir: pluginsDir,
+				Workspace:  &workspaceconfig.Workspace{With: test.with},
+			}
+			exts, diagnostics, err := Load(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(exts) != 0 {
+				t.Errorf("loaded %v", exts)
+			}
+			modules := make([]string, len(diagnostics.Errors))
+			for i, err := range diagnostics.Errors {
+				modules[i] = err.Module
+			}
+			if strings.Join(modules, " ") != strings.Join(test.errors, " ") {
+				t.Errorf("load errors = %v, want %v", modules, test.errors)
+			}
+			if strings.Join(diagnostics.Unknown, " ") != strings.Join(test.unknown, " ") {
+				t.Errorf("unknown = %v, want %v", diagnostics.Unknown, test.unknown)
+			}
+			if empty := len(test.errors) == 0 && len(test.unknown) == 0; diagnostics.Empty() != empty {
+				t.Errorf("Empty = %v, want %v", diagnostics.Empty(), empty)
+			}
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	diagnostics := &Diagnostics{
+		Errors:  []*LoadError{{"go", os.ErrNotExist}},
+		Unknown: []string{"pyhton"},
+	}
+	var b bytes.Buffer
+	diagnostics.Print(&b)
+	want := "warning: go: file does not exist\n" +
+		"warning: unknown extension 'pyhton' (run `dem plugin install pyhton` if it is not installed)\n"
+	if b.String() != want {
+		t.Errorf("Print = %q, want %q", b.String(), want)
+	}
+	if err := diagnostics.Err(); err == nil || !strings.Contains(err.Error(), "\n  go: file does not exist\n  unknown extension 'pyhton'") {
+		t.Errorf("Err = %v", err)
+	}
+	if err := new(Diagnostics).Err(); err != nil {
+		t.Errorf("Err without diagnostics = %v, want nil", err)
+	}
+}
@@ -0,0 +1,69 @@
+package ext
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"strings"
+)
+
+// Module is an extension module in the plugins directory
+type Module struct {
+	Name string
+	Path string
+	// Key is the key of the extension in the `with` section. Modules export
+	// it as `Key` if it differs from the module name.
+	Key       string
+	Extension Extension
+}
+
+// Open opens an extension module
+func Open(path string) (*Module, error) {
+	name := strings.TrimSuffix(filepath.Base(path), ".so")
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.Lookup("Export")
+	if err != nil {
+		return nil, err
+	}
+	i, ok := v.(*Extension)
+	if !ok {
+		return nil, fmt.Errorf("module %s does not export an extension", name)
+	}
+	m := &Module{
+		Name:      name,
+		Path:      path,
+		Key:       name,
+		Extension: *i,
+	}
+	if v, err := p.Lookup("Key"); err == nil {
+		if key, ok := v.(*string); ok && len(*key) > 0 {
+			m.Key = *key
+		}
+	}
+	return m, nil
+}
+
+// Modules opens the extension modules in the plugins directory, modules which
+// cannot be opened are returned as load errors
+func Modules(pluginsDir string) ([]*Module, []*LoadError, error) {
+	paths, err := filepath.Glob(filepath.Join(pluginsDir, "*.so"))
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		modules = make([]*Module, 0, len(paths))
+		errs    = make([]*LoadError, 0)
+	)
+	for _, path := range paths {
+		m, err := Open(path)
+		if err != nil {
+			errs = append(errs, &LoadError{filepath.Base(path), err})
+			continue
+		}
+		modules = append(modules, m)
+	}
+	return modules, errs, nil
+}
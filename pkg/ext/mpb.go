@@ -0,0 +1,125 @@
+package ext
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/vbauerster/mpb"
+	"github.com/vbauerster/mpb/decor"
+)
+
+var format = " · %s  "
+
+// mpbReporter renders progress bars for terminals
+type mpbReporter struct {
+	mu                 sync.Mutex
+	w                  io.Writer
+	progress           *mpb.Progress
+	tasks              map[string]SetupTasks
+	bars               map[string][]*mpb.Bar
+	taskLen, statusLen int
+}
+
+func (v *mpbReporter) Begin(name string, tasks SetupTasks) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.progress == nil {
+		v.progress = mpb.New(mpb.WithWidth(64), mpb.WithOutput(v.w))
+	}
+	if l := len(fmt.Sprintf(format, name)); l > v.taskLen {
+		v.taskLen = l
+	}
+	for _, task := range tasks {
+		if l := len(task.Status) + 1; l > v.statusLen {
+			v.statusLen = l
+		}
+	}
+	v.tasks[name] = tasks
+	v.bars[name] = make([]*mpb.Bar, len(tasks))
+}
+
+func (v *mpbReporter) Start(name string, j int) ProgressBar {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var (
+		setupTasks       = v.tasks[name]
+		setupTask        = setupTasks[j]
+		task             = fmt.Sprintf(format, strings.ToLower(name))
+		status           = setupTask.Status
+		options          = make([]mpb.BarOption, 0)
+		total      int64 = 100
+	)
+
+	if j > 0 {
+		options = append(options, mpb.BarReplaceOnComplete(v.bars[name][j-1]))
+	}
+
+	if j == len(setupTasks)-1 {
+		options = append(options, mpb.BarClearOnComplete())
+		options = append(options, mpb.PrependDecorators(
+			decor.Name(task, decor.WC{W: v.taskLen, C: decor.DidentRight}),
+			decor.OnComplete(decor.Name(status, decor.WC{W: v.statusLen, C: decor.DidentRight}), setupTask.Options.CompleteMessage),
+		))
+		if setupTask.Options.ShowPercentage {
+			options = append(options, mpb.AppendDecorators(
+				decor.OnComplete(decor.Percentage(decor.WC{W: 5}), ""),
+			))
+		}
+	} else {
+		options = append(options, mpb.BarRemoveOnComplete())
+		options = append(options, mpb.PrependDecorators(
+			decor.Name(task, decor.WC{W: v.taskLen, C: decor.DidentRight}),
+			decor.Name(status, decor.WC{W: v.statusLen, C: decor.DidentRight}),
+		))
+		if setupTask.Options.ShowPercentage {
+			options = append(options, mpb.AppendDecorators(decor.Percentage(decor.WC{W: 5})))
+		}
+	}
+
+	bar := v.progress.AddBar(total, options...)
+	v.bars[name][j] = bar
+	return bar
+}
+
+func (v *mpbReporter) Done(name string, j int, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	bar := v.bars[name][j]
+	for !bar.Completed() {
+		bar.IncrBy(1)
+	}
+	if err != nil {
+		var (
+			task   = fmt.Sprintf(format, strings.ToLower(name))
+			status = v.tasks[name][j].Status
+		)
+		repl := v.progress.AddBar(1,
+			mpb.BarClearOnComplete(),
+			mpb.BarReplaceOnComplete(bar),
+			mpb.PrependDecorators(
+				decor.Name(task, decor.WC{W: v.taskLen, C: decor.DidentRight}),
+				decor.OnComplete(decor.Name(status, decor.WC{W: v.statusLen, C: decor.DidentRight}), err.Error()),
+			),
+		)
+		repl.IncrBy(1)
+	}
+}
+
+func (v *mpbReporter) Wait() {
+	if v.progress != nil {
+		v.progress.Wait()
+	}
+}
+
+func newMpbReporter(w io.Writer) Reporter {
+	return &mpbReporter{
+		w:     w,
+		tasks: make(map[string]SetupTasks),
+		bars:  make(map[string][]*mpb.Bar),
+	}
+}
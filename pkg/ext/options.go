@@ -1,6 +1,9 @@
 package ext
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // Option type
 type Option func(*Options)
@@ -9,6 +12,11 @@ type Option func(*Options)
 type Options struct {
 	ShowPercentage  bool
 	CompleteMessage string
+	// Timeout cancels the task once exceeded, zero means no timeout
+	Timeout time.Duration
+	// Installs is the path whose installation the task completes, it is
+	// recorded once the task succeeded (see Installation.Add)
+	Installs string
 }
 
 func newOptions(opts ...Option) Options {
@@ -37,3 +45,17 @@ func CompleteMessage(s string) Option {
 		}
 	}
 }
+
+// Timeout to cancel the task if it does not complete within d
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// Installs to record path as installed once the task succeeded
+func Installs(path string) Option {
+	return func(o *Options) {
+		o.Installs = path
+	}
+}
@@ -0,0 +1,130 @@
+package ext
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// event is a single line of setup progress
+type event struct {
+	Extension string `json:"extension"`
+	Task      string `json:"task"`
+	Event     string `json:"event"`
+	Progress  int    `json:"progress,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// lineReporter writes setup progress line by line, used when the output is
+// not a terminal (e.g. CI logs)
+type lineReporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	tasks  map[string]SetupTasks
+	format func(*event, *SetupTask, bool) string
+}
+
+func (v *lineReporter) Begin(name string, tasks SetupTasks) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tasks[name] = tasks
+}
+
+func (v *lineReporter) Start(name string, j int) ProgressBar {
+	v.emit(name, j, &event{Event: "start"})
+	return &counter{
+		total: 100,
+		step:  10,
+		onStep: func(progress int) {
+			v.emit(name, j, &event{Event: "progress", Progress: progress})
+		},
+	}
+}
+
+func (v *lineReporter) Done(name string, j int, err error) {
+	if err != nil {
+		v.emit(name, j, &event{Event: "error", Error: err.Error()})
+		return
+	}
+	v.emit(name, j, &event{Event: "done"})
+}
+
+func (v *lineReporter) Wait() {}
+
+func (v *lineReporter) emit(name string, j int, e *event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	tasks := v.tasks[name]
+	e.Extension = name
+	e.Task = tasks[j].Status
+	if s := v.format(e, tasks[j], j == len(tasks)-1); len(s) > 0 {
+		fmt.Fprintln(v.w, s)
+	}
+}
+
+func plainFormat(e *event, task *SetupTask, last bool) string {
+	switch e.Event {
+	case "start":
+		return fmt.Sprintf("%s: %s", e.Extension, e.Task)
+	case "progress":
+		if task.Options.ShowPercentage {
+			return fmt.Sprintf("%s: %s %d%%", e.Extension, e.Task, e.Progress)
+		}
+	case "done":
+		if last {
+			return fmt.Sprintf("%s: %s", e.Extension, task.Options.CompleteMessage)
+		}
+	case "error":
+		return fmt.Sprintf("%s: %s failed, %s", e.Extension, e.Task, e.Error)
+	}
+	return ""
+}
+
+func jsonFormat(e *event, task *SetupTask, last bool) string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func newPlainReporter(w io.Writer) Reporter {
+	return &lineReporter{w: w, tasks: make(map[string]SetupTasks), format: plainFormat}
+}
+
+func newJSONReporter(w io.Writer) Reporter {
+	return &lineReporter{w: w, tasks: make(map[string]SetupTasks), format: jsonFormat}
+}
+
+// counter is a progress bar without rendering, it reports every time the
+// progress passes another step
+type counter struct {
+	mu      sync.Mutex
+	current int
+	total   int
+	step    int
+	last    int
+	onStep  func(int)
+}
+
+func (v *counter) IncrBy(n int, _ ...time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.current += n; v.current > v.total {
+		v.current = v.total
+	}
+	if progress := v.current * 100 / v.total; progress-v.last >= v.step || (progress == 100 && v.last != 100) {
+		v.last = progress
+		v.onStep(progress)
+	}
+}
+
+func (v *counter) Completed() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.current >= v.total
+}
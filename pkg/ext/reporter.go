@@ -0,0 +1,54 @@
+package ext
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Reporter renders the progress of extension setup tasks
+type Reporter interface {
+	// Begin registers the setup tasks of an extension, it is invoked for
+	// every extension before any of the tasks starts
+	Begin(string, SetupTasks)
+	// Start returns the progress bar of the task at given index
+	Start(string, int) ProgressBar
+	// Done marks the task at given index as completed or failed
+	Done(string, int, error)
+	// Wait blocks until all progress is rendered
+	Wait()
+}
+
+// NewReporter creates a setup reporter writing to stdout, the mode can either
+// be `auto`, `tty`, `plain` or `json`. In auto mode progress bars are only
+// rendered when stdout is a terminal.
+func NewReporter(mode string) (Reporter, error) {
+	return NewReporterTo(mode, os.Stdout)
+}
+
+// NopReporter returns a setup reporter which renders nothing
+func NopReporter() Reporter {
+	return newPlainReporter(ioutil.Discard)
+}
+
+// NewReporterTo creates a setup reporter writing to a file, see NewReporter
+func NewReporterTo(mode string, w *os.File) (Reporter, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "auto":
+		if isatty.IsTerminal(w.Fd()) {
+			return newMpbReporter(w), nil
+		}
+		return newPlainReporter(w), nil
+	case "tty":
+		return newMpbReporter(w), nil
+	case "plain":
+		return newPlainReporter(w), nil
+	case "json":
+		return newJSONReporter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown progress mode '%s'", mode)
+	}
+}
@@ -1,16 +1,28 @@
 package ext
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
-
-	"github.com/vbauerster/mpb"
-	"github.com/vbauerster/mpb/decor"
+	"time"
 )
 
-// SetupTaskHandler for implementing installation or setup instructions
-type SetupTaskHandler func(ProgressBar) error
+type timeoutKey struct{}
+
+// WithTimeout returns a context whose setup tasks are cancelled once they run
+// longer than d, unless the task sets its own timeout
+func WithTimeout(ctx context.Context, d time.Duration) context.Context {
+	if d <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, timeoutKey{}, d)
+}
+
+// SetupTaskHandler for implementing installation or setup instructions. The
+// context is done once the setup is cancelled or the task timed out, handlers
+// must then stop and leave no partial installation behind.
+type SetupTaskHandler func(context.Context, ProgressBar) error
 
 // SetupTaskHandlers is the multiple setup task handlers type
 type SetupTaskHandlers []SetupTaskHandler
@@ -25,110 +37,165 @@ type SetupTask struct {
 // SetupTasks is the multiple setup task type
 type SetupTasks []*SetupTask
 
-// Setup to run extension setup tasks
-func Setup(extensions ...Extension) error {
+// SetupError aggregates the errors of failed extension setup tasks
+type SetupError []error
+
+func (v SetupError) Error() string {
+	messages := make([]string, len(v))
+	for i, err := range v {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("extension setup failed:\n  %s", strings.Join(messages, "\n  "))
+}
+
+// Setup to run extension setup tasks, versions which are not resolved yet are
+// resolved first (see Resolver). Tasks of an extension start once the
+// extensions it requires are set up, independent extensions run in parallel.
+// Remaining tasks are skipped once the context is done.
+func Setup(ctx context.Context, reporter Reporter, extensions ...Extension) error {
+	extensions, err := Sort(extensions)
+	if err != nil {
+		return err
+	}
+	deps, err := dependencies(extensions)
+	if err != nil {
+		return err
+	}
+
+	// versions are resolved before the setup tasks are created from them
+	for _, extension := range extensions {
+		if r, ok := extension.(Resolver); ok && !r.Resolved() {
+			if err := r.Resolve(); err != nil {
+				return SetupError{fmt.Errorf("%s: %v", extension, err)}
+			}
+		}
+	}
 
 	// skip rendering progress view if all setup tasks are already completed
-	var numBars int
+	var (
+		pending = make([]Extension, 0)
+		tasks   = make(map[Extension]SetupTasks)
+		done    = make(map[Extension]chan struct{})
+	)
 	for _, extension := range extensions {
-		if len(extension.SetupTasks()) > 0 {
-			numBars++
+		done[extension] = make(chan struct{})
+		if setupTasks := extension.SetupTasks(); len(setupTasks) > 0 {
+			pending = append(pending, extension)
+			tasks[extension] = setupTasks
+		} else {
+			close(done[extension])
 		}
 	}
-	if numBars == 0 {
+	if len(pending) == 0 {
 		return nil
 	}
+	for _, extension := range pending {
+		reporter.Begin(extension.String(), tasks[extension])
+	}
 
-	// rendering progress view for setup tasks
 	var (
-		setupWg            = new(sync.WaitGroup)
-		p                  = mpb.New(mpb.WithWidth(64), mpb.WithWaitGroup(setupWg))
-		format             = " · %s  "
-		taskLen, statusLen int
+		mu      sync.Mutex
+		errs    = make(SetupError, 0)
+		failed  = make(map[Extension]bool)
+		setupWg = new(sync.WaitGroup)
 	)
-	for _, extension := range extensions {
-		if l := len(fmt.Sprintf(format, extension.String())); l > taskLen {
-			taskLen = l
-		}
-		for _, task := range extension.SetupTasks() {
-			if l := len(task.Status) + 1; l > statusLen {
-				statusLen = l
-			}
-		}
+	fail := func(extension Extension, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed[extension] = true
+		errs = append(errs, err)
 	}
-	for _, extension := range extensions {
+	for _, extension := range pending {
 		setupWg.Add(1)
 
 		go func(extension Extension) {
 			defer setupWg.Done()
-
-			var (
-				setupTasks   = extension.SetupTasks()
-				numOfTasks   = len(setupTasks)
-				progressBars = make([]*mpb.Bar, numOfTasks)
-			)
-			for j, setupTask := range setupTasks {
-				var (
-					task          = fmt.Sprintf(format, strings.ToLower(extension.String()))
-					status        = setupTask.Status
-					options       = make([]mpb.BarOption, 0)
-					total   int64 = 100
-				)
-
-				if j > 0 {
-					options = append(options, mpb.BarReplaceOnComplete(progressBars[j-1]))
+			defer close(done[extension])
+
+			name := extension.String()
+			for _, dep := range deps[extension] {
+				<-done[dep]
+				mu.Lock()
+				skip := failed[dep]
+				mu.Unlock()
+				if skip {
+					err := fmt.Errorf("requires %s", dep)
+					reporter.Start(name, 0)
+					reporter.Done(name, 0, err)
+					fail(extension, fmt.Errorf("%s: %v", name, err))
+					return
 				}
-
-				if j == numOfTasks-1 {
-					options = append(options, mpb.BarClearOnComplete())
-					options = append(options, mpb.PrependDecorators(
-						decor.Name(task, decor.WC{W: taskLen, C: decor.DidentRight}),
-						decor.OnComplete(decor.Name(status, decor.WC{W: statusLen, C: decor.DidentRight}), setupTask.Options.CompleteMessage),
-					))
-					if setupTask.Options.ShowPercentage {
-						options = append(options, mpb.AppendDecorators(
-							decor.OnComplete(decor.Percentage(decor.WC{W: 5}), ""),
-						))
-					}
-				} else {
-					options = append(options, mpb.BarRemoveOnComplete())
-					options = append(options, mpb.PrependDecorators(
-						decor.Name(task, decor.WC{W: taskLen, C: decor.DidentRight}),
-						decor.Name(status, decor.WC{W: statusLen, C: decor.DidentRight}),
-					))
-					if setupTask.Options.ShowPercentage {
-						options = append(options, mpb.AppendDecorators(decor.Percentage(decor.WC{W: 5})))
+			}
+			installer, _ := extension.(Installer)
+			steps := make([]string, 0, len(tasks[extension]))
+			for j, setupTask := range tasks[extension] {
+				bar := reporter.Start(name, j)
+				err := runTask(ctx, setupTask, bar)
+				if path := setupTask.Options.Installs; err == nil && installer != nil && len(path) > 0 {
+					if err = installer.Installation().Add(path); err != nil {
+						err = fmt.Errorf("unable to record installation, %v", err)
 					}
 				}
-
-				bar := p.AddBar(total, options...)
-				progressBars[j] = bar
-
-				err := setupTask.Handler(bar)
-				for !bar.Completed() {
-					bar.IncrBy(1)
-				}
-
+				reporter.Done(name, j, err)
 				if err != nil {
-					repl := p.AddBar(1,
-						mpb.BarClearOnComplete(),
-						mpb.BarReplaceOnComplete(bar),
-						mpb.PrependDecorators(
-							decor.Name(task, decor.WC{W: taskLen, C: decor.DidentRight}),
-							decor.OnComplete(decor.Name(status, decor.WC{W: statusLen, C: decor.DidentRight}), err.Error()),
-						),
-					)
-					repl.IncrBy(1)
-					break
+					fail(extension, fmt.Errorf("%s: %s, %v", name, setupTask.Status, err))
+					return
+				}
+				steps = append(steps, setupTask.Status)
+			}
+			// the version is recorded only once all tasks succeeded
+			if installer != nil {
+				if err := installer.Installation().Write(steps); err != nil {
+					fail(extension, fmt.Errorf("%s: unable to record installation, %v", name, err))
 				}
 			}
 		}(extension)
 	}
-	p.Wait()
+	setupWg.Wait()
+	reporter.Wait()
 
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
+func runTask(ctx context.Context, task *SetupTask, bar ProgressBar) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	timeout := task.Options.Timeout
+	if d, ok := ctx.Value(timeoutKey{}).(time.Duration); ok && timeout == 0 {
+		timeout = d
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := task.Handler(ctx, bar)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// Adopt creates the setup task recording path, which is installed but not
+// recorded (see Installation.Check), without installing it again
+func Adopt(status, path string) *SetupTask {
+	return Procedure(status, func(context.Context, ProgressBar) error {
+		return nil
+	}, Installs(path))
+}
+
+// Failure creates a setup task which fails with err, for errors of
+// extensions deciding which setup tasks are pending
+func Failure(status string, err error) *SetupTask {
+	return Procedure(status, func(context.Context, ProgressBar) error {
+		return err
+	})
+}
+
 // Procedure creates new setup task
 func Procedure(status string, handler SetupTaskHandler, opts ...Option) *SetupTask {
 	task := &SetupTask{
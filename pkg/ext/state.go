@@ -0,0 +1,294 @@
+package ext
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/util/unpack"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// Installer is implemented by extensions which record their install state.
+// Extensions decide which installations are pending from the record instead
+// of probing the installed files.
+type Installer interface {
+	Installation() *Installation
+}
+
+// Installation describes the install state record of an extension
+type Installation struct {
+	// Record is the path of the record (see StatePath)
+	Record string
+	// Version of the installation, a record of another version is incomplete
+	Version string
+	// Paths are the installed trees covered by checksums, trees modified by
+	// the user (e.g virtualenvs) are not listed
+	Paths []string
+}
+
+// State is the install state record of an extension. Installed paths are
+// recorded as their setup tasks succeed, the version once all setup tasks of
+// the extension succeeded.
+type State struct {
+	Version string `yaml:"version"`
+	// statuses of the setup tasks of the last setup
+	Steps     []string          `yaml:"steps"`
+	Checksums map[string]string `yaml:"checksums"`
+	Updated   time.Time         `yaml:"updated"`
+}
+
+// Problem is an installed path which failed verification, the path is empty
+// if the whole installation is affected
+type Problem struct {
+	Path   string
+	Reason string
+}
+
+func (v Problem) String() string {
+	if len(v.Path) == 0 {
+		return v.Reason
+	}
+	return fmt.Sprintf("%s %s", v.Path, v.Reason)
+}
+
+// StatePath returns the path of the install state record of an extension
+func StatePath(config *workspaceconfig.Config, name string) string {
+	return filepath.Join(config.InstallationDir, ".state", name+".yaml")
+}
+
+// State reads the record, it returns nil if the record does not exist
+func (v *Installation) State() (*State, error) {
+	b, err := ioutil.ReadFile(v.Record)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state *State
+	if err := yaml.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("unable to parse %s, %v", v.Record, err)
+	}
+	return state, nil
+}
+
+// Recorded reports whether the installation of the version completed
+func (v *Installation) Recorded() bool {
+	state, err := v.State()
+	return err == nil && state != nil && state.Version == v.Version
+}
+
+// InstallStatus is the status of an installed path, see Check
+type InstallStatus int
+
+// Install statuses
+const (
+	// NotInstalled paths are installed by the setup tasks
+	NotInstalled InstallStatus = iota
+	// Installed paths were recorded and their executable bin exists
+	Installed
+	// Adoptable paths are complete installations which were not recorded,
+	// e.g they were installed before install state was recorded. They are
+	// recorded by the Adopt setup task instead of being installed again.
+	Adoptable
+)
+
+// Check returns the install status of path. Installations removed since they
+// were recorded are installed again. Unrecorded directories are only adopted
+// if they were extracted by unpack, which renames them into place once
+// complete, and unrecorded files are expected to be renamed into place as
+// well. Trees left behind by interrupted installers are installed again.
+func (v *Installation) Check(path, bin string) (InstallStatus, error) {
+	state, err := v.State()
+	if err != nil {
+		return NotInstalled, err
+	}
+	if !fs.Exists(bin) {
+		return NotInstalled, nil
+	}
+	if state != nil {
+		if _, ok := state.Checksums[path]; ok {
+			return Installed, nil
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return NotInstalled, nil
+	}
+	if info.Mode().IsRegular() || unpack.Complete(path) {
+		return Adoptable, nil
+	}
+	return NotInstalled, nil
+}
+
+// Add records path as installed, so that it is not installed again if other
+// paths of the installation fail. The version of the record is only updated
+// by Write.
+func (v *Installation) Add(path string) error {
+	state, err := v.State()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = new(State)
+	}
+	if state.Checksums == nil {
+		state.Checksums = make(map[string]string)
+	}
+	sum, err := Checksum(path)
+	if err != nil {
+		return err
+	}
+	state.Checksums[path] = sum
+	state.Updated = time.Now().UTC()
+	return v.save(state)
+}
+
+// Write records the installation. Checksums of paths already recorded are
+// kept, the other paths are checksummed.
+func (v *Installation) Write(steps []string) error {
+	previous, err := v.State()
+	if err != nil {
+		return err
+	}
+	state := &State{
+		Version:   v.Version,
+		Steps:     steps,
+		Checksums: make(map[string]string, len(v.Paths)),
+		Updated:   time.Now().UTC(),
+	}
+	for _, path := range v.Paths {
+		if previous != nil {
+			if sum, ok := previous.Checksums[path]; ok {
+				state.Checksums[path] = sum
+				continue
+			}
+		}
+		sum, err := Checksum(path)
+		if err != nil {
+			return err
+		}
+		state.Checksums[path] = sum
+	}
+	return v.save(state)
+}
+
+// save writes the record atomically
+func (v *Installation) save(state *State) error {
+	b, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := fs.Mkdir(filepath.Dir(v.Record)); err != nil {
+		return err
+	}
+	tmp := v.Record + ".tmp"
+	if err := fs.WriteFile(tmp, b); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.Record)
+}
+
+// Verify compares the installed paths with the checksums of the record, it
+// returns no problems if the installation is complete and intact
+func (v *Installation) Verify() ([]Problem, error) {
+	state, err := v.State()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Version != v.Version {
+		return []Problem{{Reason: "installation is incomplete"}}, nil
+	}
+	problems := make([]Problem, 0)
+	for _, path := range v.Paths {
+		expected, ok := state.Checksums[path]
+		if !ok {
+			problems = append(problems, Problem{path, "is not installed"})
+			continue
+		}
+		if !fs.Exists(path) {
+			problems = append(problems, Problem{path, "is missing"})
+			continue
+		}
+		actual, err := Checksum(path)
+		if err != nil {
+			return nil, err
+		}
+		if actual != expected {
+			problems = append(problems, Problem{path, "is modified"})
+		}
+	}
+	return problems, nil
+}
+
+// Forget removes paths from the record so that the next setup installs them
+// again, the whole record is removed if no paths are given
+func (v *Installation) Forget(paths ...string) error {
+	state, err := v.State()
+	if err != nil || state == nil || len(paths) == 0 {
+		if err := os.Remove(v.Record); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, path := range paths {
+		delete(state.Checksums, path)
+	}
+	return v.save(state)
+}
+
+// Checksum returns the sha256 checksum of a file or directory tree, covering
+// the names, permissions, contents and symbolic link targets of its entries
+func Checksum(root string) (string, error) {
+	entries := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		entries = append(entries, path)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, path := range entries {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %s\n", rel, info.Mode())
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "-> %s\n", target)
+		case info.Mode().IsRegular():
+			fmt.Fprintf(h, "%d\n", info.Size())
+			f, err := os.Open(path)
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
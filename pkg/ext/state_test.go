@@ -0,0 +1,241 @@
+package ext
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// fake is an extension installing its paths by setup tasks
+type fake struct {
+	name         string
+	installation *Installation
+	tasks        SetupTasks
+}
+
+func (v *fake) Init(*workspaceconfig.Config) (bool, error) { return true, nil }
+func (v *fake) SetupTasks() SetupTasks                     { return v.tasks }
+func (v *fake) Environment() map[string]string             { return nil }
+func (v *fake) Aliases() map[string]string                 { return nil }
+func (v *fake) Sources() []string                          { return nil }
+func (v *fake) Paths() []string                            { return nil }
+func (v *fake) String() string                             { return v.name }
+func (v *fake) Installation() *Installation                { return v.installation }
+
+// install creates an installation tree with an executable
+func install(t *testing.T, path string) string {
+	t.Helper()
+	bin := filepath.Join(path, "bin", "tool")
+	if err := os.MkdirAll(filepath.Dir(bin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(bin, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestInstalled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "1.0")
+	bin := install(t, path)
+	unpacked(t, path)
+	installation := &Installation{Record: filepath.Join(dir, ".state", "tool.yaml"), Version: "1.0", Paths: []string{path}}
+
+	// installed before install state was recorded
+	if status, err := installation.Check(path, bin); err != nil || status != Adoptable {
+		t.Fatalf("unrecorded installation is not adopted, %v, %v", status, err)
+	}
+	if err := installation.Add(path); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := installation.Check(path, bin); status != Installed {
+		t.Fatalf("recorded installation is %v", status)
+	}
+	if installation.Recorded() {
+		t.Fatal("version is recorded before all paths are installed")
+	}
+	if err := installation.Write(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !installation.Recorded() {
+		t.Fatal("version is not recorded")
+	}
+	if problems, err := installation.Verify(); err != nil || len(problems) > 0 {
+		t.Fatalf("verify = %v, %v", problems, err)
+	}
+
+	// removed by the user
+	os.RemoveAll(path)
+	if status, _ := installation.Check(path, bin); status != NotInstalled {
+		t.Fatalf("removed installation is %v", status)
+	}
+}
+
+// unpacked marks a tree as extracted by unpack
+func unpacked(t *testing.T, path string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(path, ".unpacked"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(t *testing.T, path string) string
+		record string
+		status InstallStatus
+		err    bool
+	}{
+		{"unpacked", func(t *testing.T, path string) string {
+			bin := install(t, path)
+			unpacked(t, path)
+			return bin
+		}, "", Adoptable, false},
+		// e.g left behind by an interrupted installer
+		{"partial", func(t *testing.T, path string) string {
+			return install(t, path)
+		}, "", NotInstalled, false},
+		{"file", func(t *testing.T, path string) string {
+			os.MkdirAll(filepath.Dir(path), 0755)
+			ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755)
+			return path
+		}, "", Adoptable, false},
+		{"missing", func(t *testing.T, path string) string {
+			return filepath.Join(path, "bin", "tool")
+		}, "", NotInstalled, false},
+		{"corrupt record", func(t *testing.T, path string) string {
+			bin := install(t, path)
+			unpacked(t, path)
+			return bin
+		}, "checksums: [", NotInstalled, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "1.0")
+			bin := test.create(t, path)
+			installation := &Installation{Record: filepath.Join(dir, ".state", "tool.yaml"), Version: "1.0", Paths: []string{path}}
+			if len(test.record) > 0 {
+				os.MkdirAll(filepath.Dir(installation.Record), 0755)
+				ioutil.WriteFile(installation.Record, []byte(test.record), 0644)
+			}
+			status, err := installation.Check(path, bin)
+			if (err != nil) != test.err {
+				t.Fatalf("err = %v", err)
+			}
+			if status != test.status {
+				t.Errorf("status = %v, want %v", status, test.status)
+			}
+		})
+	}
+}
+
+func TestSetupRecordsPaths(t *testing.T) {
+	dir := t.TempDir()
+	installation := &Installation{Record: filepath.Join(dir, ".state", "tool.yaml"), Version: "1.0, 2.0"}
+	tasks := make(SetupTasks, 0)
+	for _, version := range []string{"1.0", "2.0"} {
+		path := filepath.Join(dir, version)
+		installation.Paths = append(installation.Paths, path)
+		handler := func(context.Context, ProgressBar) error {
+			install(t, path)
+			return nil
+		}
+		if version == "2.0" {
+			handler = func(context.Context, ProgressBar) error {
+				return errors.New("download failed")
+			}
+		}
+		tasks = append(tasks, Procedure("installing "+version, handler, Installs(path)))
+	}
+	extension := &fake{name: "tool", installation: installation, tasks: tasks}
+
+	err := Setup(context.Background(), newPlainReporter(ioutil.Discard), extension)
+	if err == nil {
+		t.Fatal("setup succeeded")
+	}
+	path := filepath.Join(dir, "1.0")
+	if status, _ := installation.Check(path, filepath.Join(path, "bin", "tool")); status != Installed {
+		t.Error("installed release is not recorded after another release failed")
+	}
+	if installation.Recorded() {
+		t.Error("version is recorded although a release failed")
+	}
+}
+
+func TestSetupTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     time.Duration
+		task    time.Duration
+		timeout string
+	}{
+		{"configured", 10 * time.Millisecond, 0, "timed out after 10ms"},
+		{"task overrides", time.Hour, 10 * time.Millisecond, "timed out after 10ms"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task := Procedure("waiting", func(ctx context.Context, _ ProgressBar) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}, Timeout(test.task))
+			extension := &fake{name: "tool", tasks: SetupTasks{task}}
+			ctx := WithTimeout(context.Background(), test.ctx)
+			err := Setup(ctx, newPlainReporter(ioutil.Discard), extension)
+			if err == nil || !strings.Contains(err.Error(), test.timeout) {
+				t.Fatalf("err = %v, want %s", err, test.timeout)
+			}
+		})
+	}
+}
+
+// unresolved is an extension whose version is resolved by setup
+type unresolved struct {
+	fake
+	resolved bool
+	err      error
+}
+
+func (v *unresolved) Resolved() bool { return v.resolved }
+func (v *unresolved) Resolve() error {
+	v.resolved = v.err == nil
+	return v.err
+}
+
+func TestSetupResolves(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"resolved", nil},
+		{"lookup failed", errors.New("no release matches")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ran bool
+			extension := &unresolved{err: test.err}
+			extension.name = "tool"
+			extension.installation = &Installation{Record: filepath.Join(t.TempDir(), "tool.yaml")}
+			extension.tasks = SetupTasks{Procedure("installing", func(context.Context, ProgressBar) error {
+				ran = extension.resolved
+				return nil
+			})}
+			err := Setup(context.Background(), newPlainReporter(ioutil.Discard), extension)
+			if test.err == nil && (err != nil || !ran) {
+				t.Fatalf("tasks ran with resolved version = %v, err = %v", ran, err)
+			}
+			if test.err != nil && (err == nil || ran) {
+				t.Fatalf("tasks ran after failed resolution, err = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package gc
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/workspace"
+)
+
+// Entry is an unused installation or archive
+type Entry struct {
+	Path string
+	Size int64
+}
+
+// Unused walks dir and returns the entries which are neither one of the used
+// paths nor contain one of them
+func Unused(dir string, used []string) ([]Entry, error) {
+	keep := make(map[string]bool, len(used))
+	for _, path := range used {
+		keep[filepath.Clean(path)] = true
+	}
+	return unused(dir, keep)
+}
+
+func unused(dir string, keep map[string]bool) ([]Entry, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0)
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		switch {
+		case keep[path]:
+		case file.IsDir() && contains(path, keep):
+			children, err := unused(path, keep)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, children...)
+		default:
+			size, err := Size(path)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, Entry{path, size})
+		}
+	}
+	return entries, nil
+}
+
+// contains checks whether one of the kept paths is inside of dir
+func contains(dir string, keep map[string]bool) bool {
+	prefix := dir + string(filepath.Separator)
+	for path := range keep {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns the disk usage of a file or directory, symbolic links are not
+// followed
+func Size(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Workspace returns the unused installations of an opened workspace. Versions
+// pinned by the lockfile are resolved by the extensions, so only installations
+// which are referenced by neither the configuration nor the lockfile are
+// returned.
+func Workspace(ws *workspace.Workspace) ([]Entry, error) {
+	// installations of extensions which failed to load would be removed
+	if err := ws.Diagnostics.Err(); err != nil {
+		return nil, err
+	}
+	config := ws.Config
+	used := make([]string, 0)
+	for _, extension := range ws.Extensions {
+		owner, ok := extension.(ext.Owner)
+		if !ok {
+			return nil, fmt.Errorf("extension %s does not report its installations", extension)
+		}
+		// installations of versions which are not locked cannot be told apart
+		if r, ok := extension.(ext.Resolver); ok && !r.Resolved() {
+			return nil, fmt.Errorf("versions of %s are not resolved, set up the workspace first", extension)
+		}
+		used = append(used, owner.Owns()...)
+		if installer, ok := extension.(ext.Installer); ok {
+			used = append(used, installer.Installation().Record)
+		}
+	}
+	used = append(used, workspace.HooksRecord(config))
+	return Unused(config.InstallationDir, used)
+}
+
+// Collect removes the unused installations of a workspace and writes each
+// removed path to w, nothing is removed on a dry run. It returns the number of
+// bytes freed.
+func Collect(w io.Writer, ws *workspace.Workspace, dryRun bool) (int64, error) {
+	entries, err := Workspace(ws)
+	if err != nil {
+		return 0, err
+	}
+	config := ws.Config
+	var freed int64
+	for _, entry := range entries {
+		rel, err := filepath.Rel(config.InstallationDir, entry.Path)
+		if err != nil {
+			rel = entry.Path
+		}
+		if !dryRun {
+			if err := os.RemoveAll(entry.Path); err != nil {
+				return freed, err
+			}
+		}
+		freed += entry.Size
+		fmt.Fprintf(w, "(%s) %s %s (%s)\n", config.Namespace, verb(dryRun), rel, HumanSize(entry.Size))
+	}
+	return freed, nil
+}
+
+func verb(dryRun bool) string {
+	if dryRun {
+		return "would remove"
+	}
+	return "removed"
+}
+
+// Summary returns the line printed after a collection
+func Summary(freed int64, dryRun bool) string {
+	if dryRun {
+		return fmt.Sprintf("%s would be freed", HumanSize(freed))
+	}
+	return fmt.Sprintf("%s freed", HumanSize(freed))
+}
+
+// HumanSize formats a number of bytes (e.g 1.5 MiB)
+func HumanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for i := n / unit; i >= unit; i /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
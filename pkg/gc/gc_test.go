@@ -0,0 +1,220 @@
+package gc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/workspace"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// tree creates files of given sizes below dir
+func tree(t *testing.T, dir string, files map[string]int) {
+	t.Helper()
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUnused(t *testing.T) {
+	files := map[string]int{
+		"go/1.20/bin/go":           10,
+		"go/1.21.3/bin/go":         20,
+		"go/1.21.3/pkg/tool":       5,
+		"go/go1.20.linux.tar.gz":   30,
+		"node/18.18.2/bin/node":    40,
+		"python/3.11/bin/python":   50,
+		".state/go.yaml":           1,
+		".state/node.yaml":         1,
+		".state/.post_create.yaml": 1,
+	}
+	tests := []struct {
+		name string
+		used []string
+		want map[string]int64
+	}{
+		{"nothing used", nil, map[string]int64{".state": 3, "go": 65, "node": 40, "python": 50}},
+		{
+			"installations used",
+			[]string{"go/1.21.3", "node/18.18.2", ".state/go.yaml"},
+			map[string]int64{".state/.post_create.yaml": 1, ".state/node.yaml": 1, "go/1.20": 10, "go/go1.20.linux.tar.gz": 30, "python": 50},
+		},
+		{"nested path used", []string{"go/1.21.3/bin"}, map[string]int64{".state": 3, "go/1.20": 10, "go/1.21.3/pkg": 5, "go/go1.20.linux.tar.gz": 30, "node": 40, "python": 50}},
+		{"used path missing", []string{"java/17"}, map[string]int64{".state": 3, "go": 65, "node": 40, "python": 50}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tree(t, dir, files)
+			used := make([]string, len(test.used))
+			for i, path := range test.used {
+				used[i] = filepath.Join(dir, path)
+			}
+			entries, err := Unused(dir, used)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]int64, len(entries))
+			for _, entry := range entries {
+				rel, _ := filepath.Rel(dir, entry.Path)
+				got[rel] = entry.Size
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Unused = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnusedMissingDir(t *testing.T) {
+	entries, err := Unused(filepath.Join(t.TempDir(), "missing"), nil)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Unused = %v, %v", entries, err)
+	}
+}
+
+type fake struct {
+	owns     []string
+	resolved bool
+}
+
+func (v *fake) Init(*workspaceconfig.Config) (bool, error) { return true, nil }
+func (v *fake) SetupTasks() ext.SetupTasks                 { return nil }
+func (v *fake) Environment() map[string]string             { return nil }
+func (v *fake) Aliases() map[string]string                 { return nil }
+func (v *fake) Sources() []string                          { return nil }
+func (v *fake) Paths() []string                            { return nil }
+func (v *fake) String() string                             { return "fake" }
+func (v *fake) Owns() []string                             { return v.owns }
+func (v *fake) Resolved() bool                             { return v.resolved }
+func (v *fake) Resolve() error                             { return nil }
+
+// unowned does not report its installations
+type unowned struct{ fake }
+
+func (v *unowned) Owns() {}
+
+func TestWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	tree(t, dir, map[string]int{"fake/1.0/bin/fake": 1, "fake/2.0/bin/fake": 1})
+	failed := &ext.Diagnostics{Unknown: []string{"typo"}}
+
+	tests := []struct {
+		name        string
+		extension   ext.Extension
+		diagnostics *ext.Diagnostics
+		want        []string
+		err         bool
+	}{
+		{"owned", &fake{owns: []string{filepath.Join(dir, "fake/2.0")}, resolved: true}, new(ext.Diagnostics), []string{"fake/1.0"}, false},
+		{"nothing owned", &fake{resolved: true}, new(ext.Diagnostics), []string{"fake"}, false},
+		{"load failure", &fake{resolved: true}, failed, nil, true},
+		{"unresolved", &fake{owns: []string{filepath.Join(dir, "fake/2.0")}}, new(ext.Diagnostics), nil, true},
+		{"not an owner", new(unowned), new(ext.Diagnostics), nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ws := &workspace.Workspace{
+				Namespace: "test",
+				Config: &workspaceconfig.Config{
+					Namespace:       "test",
+					InstallationDir: dir,
+					Workspace:       new(workspaceconfig.Workspace),
+				},
+				Extensions:  []ext.Extension{test.extension},
+				Diagnostics: test.diagnostics,
+			}
+			entries, err := Workspace(ws)
+			if (err != nil) != test.err {
+				t.Fatalf("Workspace err = %v, want error %v", err, test.err)
+			}
+			if test.err {
+				return
+			}
+			got := make([]string, 0)
+			for _, entry := range entries {
+				rel, _ := filepath.Rel(dir, entry.Path)
+				got = append(got, rel)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Workspace = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		dryRun bool
+		kept   []string
+	}{
+		{true, []string{".state/.post_create.yaml", "go/1.20/bin/go", "node/cache"}},
+		{false, []string{".state/.post_create.yaml"}},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		tree(t, dir, map[string]int{"go/1.20/bin/go": 1024, "node/cache": 512, ".state/.post_create.yaml": 1})
+		config := &workspaceconfig.Config{
+			Namespace:       "test",
+			PluginsDir:      filepath.Join(dir, "plugins"),
+			InstallationDir: dir,
+			Workspace:       new(workspaceconfig.Workspace),
+		}
+		ws := &workspace.Workspace{
+			Namespace:   config.Namespace,
+			Config:      config,
+			Diagnostics: new(ext.Diagnostics),
+		}
+		var out bytes.Buffer
+		freed, err := Collect(&out, ws, test.dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if freed != 1536 {
+			t.Errorf("Collect(dryRun=%v) freed %d bytes, want 1536", test.dryRun, freed)
+		}
+		kept := make([]string, 0)
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				rel, _ := filepath.Rel(dir, path)
+				kept = append(kept, rel)
+			}
+			return nil
+		})
+		sort.Strings(kept)
+		if !reflect.DeepEqual(kept, test.kept) {
+			t.Errorf("Collect(dryRun=%v) kept %v, want %v", test.dryRun, kept, test.kept)
+		}
+		if !bytes.Contains(out.Bytes(), []byte("(test) "+verb(test.dryRun)+" go (1.0 KiB)")) {
+			t.Errorf("Collect(dryRun=%v) printed %q", test.dryRun, out.String())
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	}
+	for n, want := range tests {
+		if got := HumanSize(n); got != want {
+			t.Errorf("HumanSize(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
@@ -22,6 +22,10 @@ type GlobalConfig struct {
 	// the plugin path, command line tool would load the
 	// `so` modules defined in workspace configuration
 	PluginsDir string `yaml:"plugins_dir"`
+
+	// the plugin index (local path or http url) extensions are installed
+	// from by `dem plugin install`
+	PluginIndex string `yaml:"plugin_index"`
 }
 
 // Load reads and parses global workspace configuration from yaml file
@@ -0,0 +1,163 @@
+// Package hook activates workspace environments in the shell of the user.
+// The shell hook evaluates the output of `dem export <shell>` before each
+// prompt, which exports the environment of the workspace linked to the
+// current directory and reverts it once the directory is left.
+package hook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// StateKey is the variable of the shell holding the state of the activation
+const StateKey = "DEM_HOOK"
+
+// State is the activated workspace with the values of the variables it
+// replaced, a nil value is a variable which was not set
+type State struct {
+	Namespace string             `json:"namespace"`
+	Dir       string             `json:"dir"`
+	Modified  int64              `json:"modified"`
+	Previous  map[string]*string `json:"previous"`
+	// Applied are the values exported by the activation
+	Applied map[string]string `json:"applied,omitempty"`
+	// Paths are the directories the activation added to PATH
+	Paths []string `json:"paths,omitempty"`
+	// Blocked is set if the directory was not allowed to activate the
+	// workspace
+	Blocked bool `json:"blocked,omitempty"`
+}
+
+// Decode decodes the state of the variable, it returns nil if it is invalid
+func Decode(s string) *State {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(s) == 0 {
+		return nil
+	}
+	var state *State
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil
+	}
+	return state
+}
+
+// Encode encodes the state as value of the variable
+func (v *State) Encode() string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Diff is a set of variable changes of the shell, a nil value unsets the
+// variable
+type Diff map[string]*string
+
+// Set sets a variable
+func (v Diff) Set(key, val string) {
+	v[key] = &val
+}
+
+// Unset unsets a variable
+func (v Diff) Unset(key string) {
+	v[key] = nil
+}
+
+// Revert returns the changes reverting the activation of the state in the
+// environment. Only the directories the activation added are removed from
+// PATH, and variables which were changed since the activation are kept.
+func (v *State) Revert(env map[string]string) Diff {
+	diff := make(Diff, len(v.Previous))
+	for key, val := range v.Previous {
+		current, set := env[key]
+		if key == "PATH" && v.Applied != nil {
+			if set {
+				diff.Set(key, removePaths(current, v.Paths))
+			}
+			continue
+		}
+		if applied, ok := v.Applied[key]; ok && (!set || current != applied) {
+			continue
+		}
+		diff[key] = val
+	}
+	return diff
+}
+
+// Activate returns the changes exporting the variables into the environment,
+// the values they replace and the directories they add to PATH are recorded in
+// the state
+func Activate(env map[string]string, vars map[string]string, state *State) Diff {
+	diff := make(Diff, len(vars))
+	state.Previous = make(map[string]*string, len(vars))
+	state.Applied = make(map[string]string, len(vars))
+	state.Paths = nil
+	for key, val := range vars {
+		if old, ok := env[key]; ok {
+			state.Previous[key] = &old
+		} else {
+			state.Previous[key] = nil
+		}
+		state.Applied[key] = val
+		diff.Set(key, val)
+	}
+	if path, ok := vars["PATH"]; ok {
+		existing := strings.Split(env["PATH"], ":")
+		for _, dir := range strings.Split(path, ":") {
+			if !contains(existing, dir) && !contains(state.Paths, dir) {
+				state.Paths = append(state.Paths, dir)
+			}
+		}
+	}
+	return diff
+}
+
+// removePaths removes the directories from the path list
+func removePaths(path string, dirs []string) string {
+	kept := make([]string, 0)
+	for _, dir := range strings.Split(path, ":") {
+		if !contains(dirs, dir) {
+			kept = append(kept, dir)
+		}
+	}
+	return strings.Join(kept, ":")
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Script returns the shell code applying the changes, in order of names
+func (v Diff) Script(sh Shell) string {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	script := ""
+	for _, key := range keys {
+		if !validName(key) {
+			continue
+		}
+		if val := v[key]; val != nil {
+			script += sh.Export(key, *val)
+		} else {
+			script += sh.Unset(key)
+		}
+	}
+	return script
+}
+
+func validName(key string) bool {
+	for i, c := range key {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return len(key) > 0
+}
@@ -0,0 +1,103 @@
+package hook
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRevert(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name string
+		// environment before the activation
+		env map[string]string
+		// variables of the activation
+		vars map[string]string
+		// changes of the user after the activation
+		changes map[string]string
+		want    Diff
+	}{
+		{
+			name:    "restores replaced and unsets added variables",
+			env:     map[string]string{"PATH": "/usr/bin:/bin", "GOPATH": "/go"},
+			vars:    map[string]string{"PATH": "/ws/go/bin:/usr/bin:/bin", "GOPATH": "/ws/go", "DEM_WORKSPACE": "w"},
+			changes: map[string]string{},
+			want:    Diff{"PATH": str("/usr/bin:/bin"), "GOPATH": str("/go"), "DEM_WORKSPACE": nil},
+		},
+		{
+			name:    "keeps directories added to PATH in the directory",
+			env:     map[string]string{"PATH": "/usr/bin:/bin"},
+			vars:    map[string]string{"PATH": "/ws/go/bin:/ws/node/bin:/usr/bin:/bin"},
+			changes: map[string]string{"PATH": "/venv/bin:/ws/go/bin:/ws/node/bin:/usr/bin:/bin"},
+			want:    Diff{"PATH": str("/venv/bin:/usr/bin:/bin")},
+		},
+		{
+			name:    "keeps directories which were in PATH before",
+			env:     map[string]string{"PATH": "/usr/local/bin:/usr/bin"},
+			vars:    map[string]string{"PATH": "/ws/bin:/usr/local/bin:/usr/bin"},
+			changes: map[string]string{},
+			want:    Diff{"PATH": str("/usr/local/bin:/usr/bin")},
+		},
+		{
+			name:    "keeps variables changed since the activation",
+			env:     map[string]string{"PATH": "/bin"},
+			vars:    map[string]string{"PATH": "/bin", "GOPATH": "/ws/go", "EDITOR": "vim"},
+			changes: map[string]string{"GOPATH": "/home/go"},
+			want:    Diff{"PATH": str("/bin"), "EDITOR": nil},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := &State{Namespace: "w"}
+			Activate(test.env, test.vars, state)
+			// the state is kept in the environment of the shell
+			state = Decode(state.Encode())
+
+			env := make(map[string]string)
+			for key, val := range test.env {
+				env[key] = val
+			}
+			for key, val := range test.vars {
+				env[key] = val
+			}
+			for key, val := range test.changes {
+				env[key] = val
+			}
+			if got := state.Revert(env); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Revert() = %v, want %v", format(got), format(test.want))
+			}
+		})
+	}
+}
+
+func format(diff Diff) map[string]string {
+	m := make(map[string]string, len(diff))
+	for key, val := range diff {
+		m[key] = "<unset>"
+		if val != nil {
+			m[key] = *val
+		}
+	}
+	return m
+}
+
+func TestScript(t *testing.T) {
+	val := "it's"
+	diff := Diff{"B": &val, "A": nil, "1X": nil}
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{"bash", "unset A;\nexport B='it'\\''s';\n"},
+		{"fish", "set -e A;\nset -gx B 'it\\'s';\n"},
+	}
+	for _, test := range tests {
+		sh, err := Get(test.shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := diff.Script(sh); got != test.want {
+			t.Errorf("%s script = %q, want %q", test.shell, got, test.want)
+		}
+	}
+}
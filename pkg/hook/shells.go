@@ -0,0 +1,125 @@
+package hook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Shell generates the hook and variable assignments of a shell
+type Shell interface {
+	// Hook returns the hook running the export command before each prompt,
+	// the shell name is appended to the command
+	Hook(export []string) string
+	Export(key, val string) string
+	Unset(key string) string
+}
+
+var shells = map[string]Shell{
+	"bash": bash{},
+	"zsh":  zsh{},
+	"fish": fish{},
+}
+
+// Get returns the shell of the name
+func Get(name string) (Shell, error) {
+	sh, ok := shells[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported shell '%s', expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return sh, nil
+}
+
+// Names returns the names of the supported shells
+func Names() []string {
+	names := make([]string, 0, len(shells))
+	for name := range shells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// quote quotes a string for POSIX shells
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func command(args []string, quote func(string) string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+type bash struct{}
+
+func (bash) Hook(export []string) string {
+	return fmt.Sprintf(`_dem_hook() {
+  local previous_exit_status=$?
+  trap -- '' SIGINT
+  eval "$(%s bash)"
+  trap - SIGINT
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_dem_hook;"* ]]; then
+  PROMPT_COMMAND="_dem_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`, command(export, quote))
+}
+
+func (bash) Export(key, val string) string {
+	return fmt.Sprintf("export %s=%s;\n", key, quote(val))
+}
+
+func (bash) Unset(key string) string {
+	return fmt.Sprintf("unset %s;\n", key)
+}
+
+type zsh struct {
+	bash
+}
+
+func (zsh) Hook(export []string) string {
+	return fmt.Sprintf(`_dem_hook() {
+  trap -- '' SIGINT
+  eval "$(%s zsh)"
+  trap - SIGINT
+}
+typeset -ag precmd_functions chpwd_functions
+if (( ! ${precmd_functions[(I)_dem_hook]} )); then
+  precmd_functions=(_dem_hook $precmd_functions)
+fi
+if (( ! ${chpwd_functions[(I)_dem_hook]} )); then
+  chpwd_functions=(_dem_hook $chpwd_functions)
+fi
+`, command(export, quote))
+}
+
+type fish struct{}
+
+func (fish) Hook(export []string) string {
+	return fmt.Sprintf(`function __dem_hook --on-event fish_prompt
+    %s fish | source
+end
+`, command(export, fishQuote))
+}
+
+// Export assigns the variable, variables named *PATH are path variables in
+// fish, which are lists of directories
+func (fish) Export(key, val string) string {
+	values := []string{val}
+	if strings.HasSuffix(key, "PATH") {
+		values = strings.Split(val, ":")
+	}
+	return fmt.Sprintf("set -gx %s %s;\n", key, command(values, fishQuote))
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func (fish) Unset(key string) string {
+	return fmt.Sprintf("set -e %s;\n", key)
+}
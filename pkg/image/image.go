@@ -0,0 +1,370 @@
+// Package image writes OCI image layouts without a container runtime
+package image
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPath is the PATH of images without a base image
+const DefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// Image is an image with a single layer of files on top of an optional base
+// image. Files keep their absolute paths, so that paths in the environment
+// and startup files remain valid in containers.
+type Image struct {
+	// Base is the OCI image layout directory of the base image, the image has
+	// no other layers if it is empty
+	Base string
+	// Tag is the name of the image in the layout index
+	Tag string
+	// Files are the absolute paths of the files and directories of the layer
+	Files []string
+	Env   map[string]string
+	// Path is prepended to the PATH of the base image
+	Path       []string
+	WorkingDir string
+	Entrypoint []string
+	// CreatedBy describes the layer in the image history
+	CreatedBy string
+}
+
+// modification time of the files of the layout, blobs are content addressed
+var epoch = time.Unix(0, 0)
+
+type blob struct {
+	desc descriptor
+	// path of the blob content, data is used if empty
+	path string
+	data []byte
+}
+
+// Write writes the image as OCI image layout tarball
+func (v *Image) Write(w io.Writer) error {
+	var (
+		conf    = &config{Architecture: runtime.GOARCH, OS: runtime.GOOS}
+		blobs   = make([]*blob, 0)
+		created = time.Now().UTC()
+	)
+	conf.RootFS.Type = "layers"
+	if len(v.Entrypoint) == 0 {
+		return fmt.Errorf("image has no entrypoint")
+	}
+	if len(v.Base) > 0 {
+		m, c, err := layout(v.Base).image()
+		if err != nil {
+			return fmt.Errorf("unable to read base image, %v", err)
+		}
+		if !v.contains(v.Entrypoint[0]) {
+			files, err := layout(v.Base).files(m.Layers)
+			if err != nil {
+				return fmt.Errorf("unable to read base image, %v", err)
+			}
+			if !exists(files, v.Entrypoint[0]) {
+				return fmt.Errorf("entrypoint %s is neither in the base image nor in the workspace", v.Entrypoint[0])
+			}
+		}
+		conf = c
+		for _, layer := range m.Layers {
+			path, err := layout(v.Base).blobPath(layer.Digest)
+			if err != nil {
+				return err
+			}
+			blobs = append(blobs, &blob{desc: layer, path: path})
+		}
+	} else if !v.contains(v.Entrypoint[0]) {
+		return fmt.Errorf("entrypoint %s is not in the workspace, a base image which contains it is required", v.Entrypoint[0])
+	}
+
+	layerPath, layer, diffID, err := v.layer()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(layerPath)
+	blobs = append(blobs, &blob{desc: layer, path: layerPath})
+
+	conf.Created = &created
+	conf.RootFS.DiffIDs = append(conf.RootFS.DiffIDs, diffID)
+	conf.History = append(conf.History, history{Created: &created, CreatedBy: v.CreatedBy})
+	conf.Config.Env = v.environment(conf.Config.Env)
+	conf.Config.Entrypoint = v.Entrypoint
+	conf.Config.Cmd = nil
+	conf.Config.WorkingDir = v.WorkingDir
+
+	configBlob, err := jsonBlob(MediaTypeConfig, conf)
+	if err != nil {
+		return err
+	}
+	layers := make([]descriptor, 0, len(blobs))
+	for _, b := range blobs {
+		layers = append(layers, b.desc)
+	}
+	manifestBlob, err := jsonBlob(MediaTypeManifest, &manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeManifest,
+		Config:        configBlob.desc,
+		Layers:        layers,
+	})
+	if err != nil {
+		return err
+	}
+	blobs = append(blobs, configBlob, manifestBlob)
+
+	desc := manifestBlob.desc
+	desc.Annotations = map[string]string{refName: v.Tag}
+	desc.Platform = &platform{Architecture: conf.Architecture, OS: conf.OS}
+	idx, err := json.Marshal(&index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeIndex,
+		Manifests:     []descriptor{desc},
+	})
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeFile(tw, "oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, b := range blobs {
+		if written[b.desc.Digest] {
+			continue
+		}
+		written[b.desc.Digest] = true
+		if err := b.write(tw); err != nil {
+			return err
+		}
+	}
+	if err := writeFile(tw, "index.json", idx); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// environment merges the environment of the image into the environment of
+// the base image
+func (v *Image) environment(base []string) []string {
+	env := make(map[string]string)
+	for _, s := range base {
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+	path, ok := env["PATH"]
+	if !ok {
+		path = DefaultPath
+	}
+	for key, val := range v.Env {
+		env[key] = val
+	}
+	paths := append([]string{}, v.Path...)
+	for _, dir := range strings.Split(path, ":") {
+		if !contains(v.Path, dir) {
+			paths = append(paths, dir)
+		}
+	}
+	env["PATH"] = strings.Join(paths, ":")
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	vars := make([]string, len(keys))
+	for i, key := range keys {
+		vars[i] = fmt.Sprintf("%s=%s", key, env[key])
+	}
+	return vars
+}
+
+// contains reports whether the path exists in the files of the layer
+func (v *Image) contains(path string) bool {
+	for _, root := range v.Files {
+		if path == root || strings.HasPrefix(path, root+"/") {
+			_, err := os.Stat(path)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// layer writes the compressed layer to a temporary file, it returns the path
+// of the file, its descriptor and the digest of the uncompressed layer
+func (v *Image) layer() (string, descriptor, string, error) {
+	f, err := ioutil.TempFile("", "dem-layer-")
+	if err != nil {
+		return "", descriptor{}, "", err
+	}
+	defer f.Close()
+	var (
+		compressed   = sha256.New()
+		uncompressed = sha256.New()
+		counter      = new(countWriter)
+		gz           = gzip.NewWriter(io.MultiWriter(f, compressed, counter))
+		tw           = tar.NewWriter(io.MultiWriter(gz, uncompressed))
+	)
+	if err := v.archive(tw); err != nil {
+		os.Remove(f.Name())
+		return "", descriptor{}, "", err
+	}
+	if err := tw.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", descriptor{}, "", err
+	}
+	if err := gz.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", descriptor{}, "", err
+	}
+	desc := descriptor{
+		MediaType: MediaTypeLayer,
+		Digest:    digest(compressed),
+		Size:      counter.n,
+	}
+	return f.Name(), desc, digest(uncompressed), nil
+}
+
+// archive writes the files of the layer and their parent directories
+func (v *Image) archive(tw *tar.Writer) error {
+	written := make(map[string]bool)
+	for _, root := range v.Files {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("path %s is not absolute", root)
+		}
+		if _, err := os.Lstat(root); os.IsNotExist(err) {
+			continue
+		}
+		parents := make([]string, 0)
+		for dir := filepath.Dir(root); dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+			parents = append([]string{dir}, parents...)
+		}
+		for _, dir := range parents {
+			if err := addPath(tw, dir, written); err != nil {
+				return err
+			}
+		}
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return addPath(tw, path, written)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addPath(tw *tar.Writer, path string, written map[string]bool) error {
+	if written[path] {
+		return nil
+	}
+	written[path] = true
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(path, "/")
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func (v *blob) write(tw *tar.Writer) error {
+	name := filepath.Join("blobs", strings.Replace(v.desc.Digest, ":", "/", 1))
+	if len(v.path) == 0 {
+		return writeFile(tw, name, v.data)
+	}
+	f, err := os.Open(v.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: epoch}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: epoch}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonBlob(mediaType string, v interface{}) (*blob, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(b)
+	return &blob{desc: descriptor{MediaType: mediaType, Digest: digest(h), Size: int64(len(b))}, data: b}, nil
+}
+
+func digest(h hash.Hash) string {
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (v *countWriter) Write(p []byte) (int, error) {
+	v.n += int64(len(p))
+	return len(p), nil
+}
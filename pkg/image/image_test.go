@@ -0,0 +1,112 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type file struct {
+	name, link string
+}
+
+// writeLayout writes an OCI image layout with a layer for each list of files
+func writeLayout(t *testing.T, dir string, layers ...[]file) {
+	t.Helper()
+	blob := func(b []byte) descriptor {
+		h := sha256.New()
+		h.Write(b)
+		desc := descriptor{Digest: digest(h), Size: int64(len(b))}
+		path, _ := layout(dir).blobPath(desc.Digest)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	m := &manifest{SchemaVersion: 2, MediaType: MediaTypeManifest}
+	for _, files := range layers {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, f := range files {
+			header := &tar.Header{Name: f.name, Mode: 0755, Typeflag: tar.TypeReg}
+			if f.link != "" {
+				header.Typeflag, header.Linkname = tar.TypeSymlink, f.link
+			}
+			tw.WriteHeader(header)
+		}
+		tw.Close()
+		gz.Close()
+		desc := blob(buf.Bytes())
+		desc.MediaType = MediaTypeLayer
+		m.Layers = append(m.Layers, desc)
+	}
+	c, _ := json.Marshal(&config{Architecture: runtime.GOARCH, OS: runtime.GOOS})
+	m.Config = blob(c)
+	b, _ := json.Marshal(m)
+	desc := blob(b)
+	desc.MediaType = MediaTypeManifest
+	idx, _ := json.Marshal(&index{SchemaVersion: 2, Manifests: []descriptor{desc}})
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.json"), idx, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteEntrypoint(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base")
+	writeLayout(t, base, []file{
+		{name: "bin", link: "usr/bin"},
+		{name: "usr/bin/sh"},
+		{name: "usr/bin/zsh"},
+		{name: "usr/local/bin/fish"},
+	}, []file{
+		{name: "usr/bin/.wh.zsh"},
+		{name: "usr/local/.wh..wh..opq"},
+	})
+	workspace := filepath.Join(dir, "workspace")
+	installed := filepath.Join(workspace, "bin", "shell")
+	os.MkdirAll(filepath.Dir(installed), 0755)
+	ioutil.WriteFile(installed, nil, 0755)
+
+	tests := []struct {
+		name       string
+		base       string
+		entrypoint string
+		err        string
+	}{
+		{"base through symlink", base, "/bin/sh", ""},
+		{"base", base, "/usr/bin/sh", ""},
+		{"removed by whiteout", base, "/bin/zsh", "neither in the base image nor in the workspace"},
+		{"removed by opaque whiteout", base, "/usr/local/bin/fish", "neither in the base image nor in the workspace"},
+		{"missing in base", base, "/bin/bash", "neither in the base image nor in the workspace"},
+		{"workspace without base", "", installed, ""},
+		{"no base", "", "/bin/sh", "a base image which contains it is required"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := &Image{
+				Base:       test.base,
+				Tag:        "latest",
+				Files:      []string{workspace},
+				Entrypoint: []string{test.entrypoint, "-l"},
+			}
+			err := img.Write(ioutil.Discard)
+			if test.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("err = %v, want %s", err, test.err)
+			}
+		})
+	}
+}
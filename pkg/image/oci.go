@@ -0,0 +1,276 @@
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Media types of the OCI image specification
+const (
+	MediaTypeIndex    = "application/vnd.oci.image.index.v1+json"
+	MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeConfig   = "application/vnd.oci.image.config.v1+json"
+	MediaTypeLayer    = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	dockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// annotation of the index with the tag of an image
+const refName = "org.opencontainers.image.ref.name"
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *platform         `json:"platform,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+type index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []descriptor `json:"manifests"`
+}
+
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+type config struct {
+	Created      *time.Time      `json:"created,omitempty"`
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	Variant      string          `json:"variant,omitempty"`
+	Config       containerConfig `json:"config"`
+	RootFS       rootFS          `json:"rootfs"`
+	History      []history       `json:"history,omitempty"`
+}
+
+type containerConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+type rootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+type history struct {
+	Created   *time.Time `json:"created,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+}
+
+// layout is an OCI image layout directory
+type layout string
+
+func (v layout) blobPath(digest string) (string, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || strings.ContainsAny(parts[1], `/\`) {
+		return "", fmt.Errorf("invalid digest '%s'", digest)
+	}
+	return filepath.Join(string(v), "blobs", parts[0], parts[1]), nil
+}
+
+func (v layout) read(digest string, out interface{}) error {
+	path, err := v.blobPath(digest)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// image returns the manifest and configuration of the image in the layout,
+// the image of the current platform is chosen from multi-platform images
+func (v layout) image() (*manifest, *config, error) {
+	var idx index
+	b, err := ioutil.ReadFile(filepath.Join(string(v), "index.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse index.json, %v", err)
+	}
+	if len(idx.Manifests) == 0 {
+		return nil, nil, fmt.Errorf("%s does not contain images", v)
+	}
+	desc := idx.Manifests[0]
+	if desc.MediaType == MediaTypeIndex || desc.MediaType == dockerManifestList {
+		var nested index
+		if err := v.read(desc.Digest, &nested); err != nil {
+			return nil, nil, err
+		}
+		found := false
+		for _, m := range nested.Manifests {
+			if m.Platform != nil && m.Platform.OS == runtime.GOOS && m.Platform.Architecture == runtime.GOARCH {
+				desc, found = m, true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%s does not contain an image for %s/%s", v, runtime.GOOS, runtime.GOARCH)
+		}
+	}
+	var m manifest
+	if err := v.read(desc.Digest, &m); err != nil {
+		return nil, nil, err
+	}
+	var c config
+	if err := v.read(m.Config.Digest, &c); err != nil {
+		return nil, nil, err
+	}
+	return &m, &c, nil
+}
+
+// prefixes of the whiteout files of layers, which remove files of lower layers
+const (
+	whiteout       = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// files returns the files of the layers as absolute paths, symbolic links are
+// mapped to their targets and other files to an empty string
+func (v layout) files(layers []descriptor) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, desc := range layers {
+		p, err := v.blobPath(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		layer, err := readLayer(p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read layer %s, %v", desc.Digest, err)
+		}
+		// whiteouts remove the files of lower layers only
+		for name := range layer {
+			dir, base := path.Split(name)
+			if !strings.HasPrefix(base, whiteout) {
+				continue
+			}
+			delete(layer, name)
+			removed := path.Join(dir, strings.TrimPrefix(base, whiteout))
+			if base == opaqueWhiteout {
+				removed = path.Clean(dir)
+			} else {
+				delete(files, removed)
+			}
+			for file := range files {
+				if strings.HasPrefix(file, removed+"/") {
+					delete(files, file)
+				}
+			}
+		}
+		for name, link := range layer {
+			files[name] = link
+		}
+	}
+	return files, nil
+}
+
+// readLayer returns the files of a layer tarball, compressed or not
+func readLayer(p string) (map[string]string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var (
+		r     io.Reader = bufio.NewReader(f)
+		files           = make(map[string]string)
+	)
+	if magic, err := r.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		} else if err != nil {
+			return nil, err
+		}
+		name := path.Join("/", header.Name)
+		files[name] = ""
+		if header.Typeflag == tar.TypeSymlink {
+			files[name] = header.Linkname
+		}
+	}
+}
+
+// exists reports whether the path exists in files, symbolic links of its
+// components are followed
+func exists(files map[string]string, name string) bool {
+	for i := 0; i < 40; i++ {
+		var (
+			parts    = strings.Split(strings.TrimPrefix(path.Clean(name), "/"), "/")
+			resolved = false
+		)
+		for j := range parts {
+			current := "/" + strings.Join(parts[:j+1], "/")
+			link, ok := files[current]
+			if !ok && !parent(files, current) {
+				return false
+			}
+			if link == "" {
+				continue
+			}
+			if !path.IsAbs(link) {
+				link = path.Join(path.Dir(current), link)
+			}
+			name = path.Join(append([]string{link}, parts[j+1:]...)...)
+			resolved = true
+			break
+		}
+		if !resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// parent reports whether dir is the parent of files, layers do not always
+// contain the entries of the directories
+func parent(files map[string]string, dir string) bool {
+	for name := range files {
+		if strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// prefix of the features which install toolchains
+const featurePrefix = "ghcr.io/devcontainers/features/"
+
+// lifecycle commands which run once after the container is created, in order
+var createCommands = []string{"onCreateCommand", "updateContentCommand", "postCreateCommand"}
+
+// properties which are translated or which have no effect on the workspace
+var translated = map[string]bool{
+	"$schema":              true,
+	"name":                 true,
+	"features":             true,
+	"containerEnv":         true,
+	"remoteEnv":            true,
+	"onCreateCommand":      true,
+	"updateContentCommand": true,
+	"postCreateCommand":    true,
+}
+
+// Devcontainer translates a devcontainer.json configuration
+// (https://containers.dev/implementors/json_reference)
+func Devcontainer(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(jsonc(b), &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse %s, %v", path, err)
+	}
+	conf := new(Config)
+
+	var features map[string]map[string]interface{}
+	if raw, ok := doc["features"]; ok {
+		if err := json.Unmarshal(raw, &features); err != nil {
+			return nil, fmt.Errorf("unable to parse features, %v", err)
+		}
+	}
+	for _, id := range sortedKeys(features) {
+		conf.feature(id, features[id])
+	}
+
+	for _, key := range []string{"containerEnv", "remoteEnv"} {
+		var env map[string]string
+		if raw, ok := doc[key]; ok {
+			if err := json.Unmarshal(raw, &env); err != nil {
+				return nil, fmt.Errorf("unable to parse %s, %v", key, err)
+			}
+		}
+		for _, name := range sortedKeys(env) {
+			if val := env[name]; strings.Contains(val, "${") {
+				conf.unmapped("%s %s refers to devcontainer variables (%s)", key, name, val)
+			} else {
+				conf.env(name, val)
+			}
+		}
+	}
+
+	for _, key := range createCommands {
+		raw, ok := doc[key]
+		if !ok {
+			continue
+		}
+		commands, err := lifecycleCommand(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s, %v", key, err)
+		}
+		conf.PostCreate = append(conf.PostCreate, commands...)
+	}
+
+	for _, key := range sortedKeys(doc) {
+		if !translated[key] {
+			conf.unmapped("property '%s' has no equivalent", key)
+		}
+	}
+	return conf, nil
+}
+
+// feature translates a devcontainer feature, e.g
+// ghcr.io/devcontainers/features/node:1
+func (v *Config) feature(id string, options map[string]interface{}) {
+	tool := strings.TrimPrefix(id, featurePrefix)
+	if n := strings.IndexAny(tool, ":@"); n >= 0 {
+		tool = tool[:n]
+	}
+	if _, ok := extensions[tool]; !ok || !strings.HasPrefix(id, featurePrefix) {
+		v.unmapped("feature '%s' has no extension", id)
+		return
+	}
+	version := option(options, "version")
+	if version == "none" {
+		return
+	}
+	if len(version) == 0 {
+		version = "latest"
+	}
+	extra := make([]yaml.MapItem, 0)
+	if tool == "java" {
+		for _, t := range []struct{ install, version, key string }{
+			{"installMaven", "mavenVersion", "maven"},
+			{"installGradle", "gradleVersion", "gradle"},
+		} {
+			if option(options, t.install) != "true" {
+				continue
+			}
+			if version := option(options, t.version); len(version) > 0 && version != "latest" {
+				extra = append(extra, yaml.MapItem{Key: t.key, Value: version})
+			} else {
+				v.unmapped("%s of feature '%s' has no fixed version", t.key, id)
+			}
+		}
+	}
+	v.tool(tool, []string{version}, extra...)
+}
+
+// option returns a feature option as string, options may be booleans
+func option(options map[string]interface{}, key string) string {
+	val, ok := options[key]
+	if !ok || val == nil {
+		return ""
+	}
+	return fmt.Sprint(val)
+}
+
+// lifecycleCommand returns the shell commands of a lifecycle command, which
+// is a string, an array of arguments or an object of commands run in
+// parallel (they are run in the order of their names)
+func lifecycleCommand(raw json.RawMessage) ([]string, error) {
+	var command interface{}
+	if err := json.Unmarshal(raw, &command); err != nil {
+		return nil, err
+	}
+	switch c := command.(type) {
+	case string:
+		return []string{c}, nil
+	case []interface{}:
+		return []string{quote(c)}, nil
+	case map[string]interface{}:
+		commands := make([]string, 0, len(c))
+		for _, name := range sortedKeys(c) {
+			switch cmd := c[name].(type) {
+			case string:
+				commands = append(commands, cmd)
+			case []interface{}:
+				commands = append(commands, quote(cmd))
+			default:
+				return nil, fmt.Errorf("invalid command '%s'", name)
+			}
+		}
+		return commands, nil
+	}
+	return nil, fmt.Errorf("invalid command")
+}
+
+// quote joins the arguments of a command into a shell command
+func quote(args []interface{}) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.Replace(fmt.Sprint(arg), "'", `'\''`, -1) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// jsonc strips the comments and trailing commas of JSON with comments
+func jsonc(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '"':
+			start := i
+			for i++; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(b) {
+				i = len(b) - 1
+			}
+			out = append(out, b[start:i+1]...)
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := strings.Index(string(b[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == ']' || c == '}':
+			// drops the comma before the closing bracket
+			n := len(out) - 1
+			for n >= 0 && strings.ContainsRune(" \t\r\n", rune(out[n])) {
+				n--
+			}
+			if n >= 0 && out[n] == ',' {
+				out = append(out[:n], out[n+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	switch m := m.(type) {
+	case map[string]map[string]interface{}:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]interface{}:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]string:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]json.RawMessage:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
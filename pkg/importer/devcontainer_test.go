@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONC(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"plain", `{"a": [1, 2]}`, `{"a": [1, 2]}`},
+		{"line comments", "{\n  // comment\n  \"a\": 1 // trailing\n}", "{\n  \n  \"a\": 1 \n}"},
+		{"block comments", `{/* a */"a": /* b */ 1}`, `{"a":  1}`},
+		{"trailing commas", "{\"a\": [1, 2,],\n}", "{\"a\": [1, 2]\n}"},
+		{"trailing comma before comment", "[1, // last\n]", "[1 \n]"},
+		{"comments in strings", `{"url": "http://host/*x*/"}`, `{"url": "http://host/*x*/"}`},
+		{"escaped quotes", `{"a": "say \"hi\" // no", "b": "\\"}`, `{"a": "say \"hi\" // no", "b": "\\"}`},
+		{"commas in strings", `["a,"]`, `["a,"]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := string(jsonc([]byte(test.src)))
+			if got != test.want {
+				t.Errorf("jsonc(%q) = %q, want %q", test.src, got, test.want)
+			}
+			var v interface{}
+			if err := json.Unmarshal([]byte(got), &v); err != nil {
+				t.Errorf("jsonc(%q) is not valid JSON, %v", test.src, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Package importer translates the toolchain definitions of other tools
+// (.tool-versions, devcontainer.json and mise.toml) into workspace
+// configurations
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is a workspace configuration translated from another tool
+type Config struct {
+	// With are the extension configurations by extension name
+	With        yaml.MapSlice
+	Environment yaml.MapSlice
+	PostCreate  []string
+	// Unmapped describes the definitions which have no equivalent
+	Unmapped []string
+}
+
+// names of the extensions by tool name
+var extensions = map[string]string{
+	"node":   "node",
+	"nodejs": "node",
+	"go":     "go",
+	"golang": "go",
+	"python": "python",
+	"ruby":   "ruby",
+	"rust":   "rust",
+	"java":   "java",
+}
+
+// extensions which install several versions of their toolchain
+var multiVersion = map[string]bool{
+	"node": true,
+	"go":   true,
+	"ruby": true,
+	"rust": true,
+}
+
+// javaVersion matches java versions with an optional distribution prefix, e.g
+// temurin-17.0.9+9 or openjdk-21
+var javaVersion = regexp.MustCompile(`^(?:([a-z][a-z0-9.]*?)-)?(\d+)`)
+
+// Open translates a file, its format is chosen by its name
+func Open(path string) (*Config, error) {
+	name := filepath.Base(path)
+	switch {
+	case name == ".tool-versions":
+		return ToolVersions(path)
+	case strings.HasSuffix(name, ".json"):
+		return Devcontainer(path)
+	case strings.HasSuffix(name, ".toml") && strings.Contains(name, "mise"):
+		return Mise(path)
+	default:
+		return nil, fmt.Errorf("unsupported file '%s', expected .tool-versions, devcontainer.json or mise.toml", name)
+	}
+}
+
+func (v *Config) unmapped(format string, args ...interface{}) {
+	v.Unmapped = append(v.Unmapped, fmt.Sprintf(format, args...))
+}
+
+// tool adds the configuration of an extension for the versions of a tool,
+// the first version is the default one
+func (v *Config) tool(tool string, versions []string, options ...yaml.MapItem) {
+	name, ok := extensions[tool]
+	if !ok {
+		v.unmapped("tool '%s' has no extension", tool)
+		return
+	}
+	if len(versions) == 0 {
+		v.unmapped("tool '%s' has no version", tool)
+		return
+	}
+	// the python extension installs standalone builds, which are published
+	// by release date and cannot be derived from the version
+	if name == "python" {
+		v.unmapped("python %s requires the release of a standalone build (e.g release: 20231002)", strings.Join(versions, ", "))
+		return
+	}
+	normalized := make([]string, 0, len(versions))
+	for _, version := range versions {
+		if version, ok := v.version(name, version); ok && !contains(normalized, version) {
+			normalized = append(normalized, version)
+		}
+	}
+	if len(normalized) == 0 {
+		return
+	}
+	if len(normalized) > 1 && !multiVersion[name] {
+		v.unmapped("%s versions %s, only %s is installed", name, strings.Join(normalized[1:], ", "), normalized[0])
+		normalized = normalized[:1]
+	}
+	conf := yaml.MapSlice{}
+	if len(normalized) == 1 {
+		conf = append(conf, yaml.MapItem{Key: "version", Value: normalized[0]})
+	} else {
+		conf = append(conf,
+			yaml.MapItem{Key: "versions", Value: normalized},
+			yaml.MapItem{Key: "default", Value: normalized[0]},
+		)
+	}
+	conf = append(conf, options...)
+	v.With = set(v.With, name, conf)
+}
+
+// version translates the version of a tool, it reports versions which cannot
+// be installed by the extension
+func (v *Config) version(name, version string) (string, bool) {
+	switch {
+	case version == "system" || version == "os-provided":
+		v.unmapped("%s version '%s' refers to the host installation", name, version)
+		return "", false
+	case strings.HasPrefix(version, "ref:") || strings.HasPrefix(version, "path:") || strings.HasPrefix(version, "prefix:"):
+		v.unmapped("%s version '%s' is not a release", name, version)
+		return "", false
+	case name == "java":
+		m := javaVersion.FindStringSubmatch(version)
+		if m == nil {
+			v.unmapped("java version '%s' is not supported", version)
+			return "", false
+		}
+		if dist := m[1]; len(dist) > 0 && dist != "temurin" && dist != "openjdk" && dist != "adoptopenjdk" {
+			v.unmapped("java distribution '%s' is installed as temurin %s", dist, m[2])
+		}
+		return m[2], true
+	case name == "rust" && version == "latest":
+		return "stable", true
+	case version == "latest" || version == "lts":
+		v.unmapped("%s version '%s' is not fixed", name, version)
+		return "", false
+	}
+	return version, true
+}
+
+// env adds an environment variable
+func (v *Config) env(key, val string) {
+	v.Environment = set(v.Environment, key, val)
+}
+
+// Merge merges the translated configuration into the yaml source of a
+// workspace configuration. Extension configurations and environment variables
+// are replaced, hooks are appended. Comments of the source are not kept.
+func (v *Config) Merge(src []byte) ([]byte, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+	workspace, _ := get(doc, "workspace").(yaml.MapSlice)
+	if len(v.With) > 0 {
+		with, _ := get(workspace, "with").(yaml.MapSlice)
+		for _, item := range v.With {
+			with = set(with, item.Key, item.Value)
+		}
+		workspace = set(workspace, "with", with)
+	}
+	if len(v.Environment) > 0 {
+		env, _ := get(workspace, "environment").(yaml.MapSlice)
+		for _, item := range v.Environment {
+			env = set(env, item.Key, item.Value)
+		}
+		workspace = set(workspace, "environment", env)
+	}
+	if len(v.PostCreate) > 0 {
+		hooks, _ := get(workspace, "hooks").(yaml.MapSlice)
+		commands := make([]interface{}, 0)
+		if existing, ok := get(hooks, "post_create").([]interface{}); ok {
+			commands = append(commands, existing...)
+		}
+		for _, command := range v.PostCreate {
+			found := false
+			for _, existing := range commands {
+				found = found || existing == command
+			}
+			if !found {
+				commands = append(commands, command)
+			}
+		}
+		workspace = set(workspace, "hooks", set(hooks, "post_create", commands))
+	}
+	doc = set(doc, "workspace", workspace)
+	return yaml.Marshal(doc)
+}
+
+func get(m yaml.MapSlice, key interface{}) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// set replaces the value of the key, or appends it
+func set(m yaml.MapSlice, key, val interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = val
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: val})
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestToolVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tool-versions")
+	src := `nodejs 20.9.0 18.18.2 # default first
+golang 1.21.3
+python 3.11.6
+java temurin-17.0.9+9
+ruby system
+rust latest
+terraform 1.6.0
+`
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := yaml.MapSlice{
+		{Key: "node", Value: yaml.MapSlice{
+			{Key: "versions", Value: []string{"20.9.0", "18.18.2"}},
+			{Key: "default", Value: "20.9.0"},
+		}},
+		{Key: "go", Value: yaml.MapSlice{{Key: "version", Value: "1.21.3"}}},
+		{Key: "java", Value: yaml.MapSlice{{Key: "version", Value: "17"}}},
+		{Key: "rust", Value: yaml.MapSlice{{Key: "version", Value: "stable"}}},
+	}
+	if !reflect.DeepEqual(conf.With, want) {
+		t.Errorf("with = %#v, want %#v", conf.With, want)
+	}
+	unmapped := strings.Join(conf.Unmapped, "\n")
+	for _, s := range []string{"python 3.11.6 requires the release", "ruby version 'system'", "tool 'terraform'"} {
+		if !strings.Contains(unmapped, s) {
+			t.Errorf("%q is not reported in:\n%s", s, unmapped)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	conf := &Config{
+		With:       yaml.MapSlice{{Key: "go", Value: yaml.MapSlice{{Key: "version", Value: "1.21.3"}}}},
+		PostCreate: []string{"make deps"},
+	}
+	src := `workspace:
+  with:
+    go:
+      version: "1.20"
+    node:
+      version: "20"
+  hooks:
+    post_create:
+    - make deps
+`
+	b, err := conf.Merge([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `workspace:
+  with:
+    go:
+      version: 1.21.3
+    node:
+      version: "20"
+  hooks:
+    post_create:
+    - make deps
+`
+	if string(b) != want {
+		t.Errorf("Merge() =\n%s\nwant\n%s", b, want)
+	}
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Mise translates a mise.toml configuration
+// (https://mise.jdx.dev/configuration.html)
+func Mise(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseTOML(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s, %v", path, err)
+	}
+	conf := new(Config)
+	reported := make(map[string]bool)
+	for _, entry := range entries {
+		switch entry.Table {
+		case "tools":
+			conf.miseTool(entry.Key, entry.Value)
+		case "env":
+			val, ok := entry.Value.(string)
+			if strings.HasPrefix(entry.Key, "_") || !ok {
+				conf.unmapped("env %s has no equivalent", entry.Key)
+				continue
+			}
+			conf.env(entry.Key, val)
+		case "hooks":
+			commands, ok := stringList(entry.Value)
+			if entry.Key != "postinstall" || !ok {
+				conf.unmapped("hook %s has no equivalent", entry.Key)
+				continue
+			}
+			conf.PostCreate = append(conf.PostCreate, commands...)
+		case "":
+			conf.unmapped("setting %s has no equivalent", entry.Key)
+		default:
+			table := strings.SplitN(entry.Table, ".", 2)[0]
+			if !reported[table] {
+				reported[table] = true
+				conf.unmapped("table [%s] has no equivalent", table)
+			}
+		}
+	}
+	return conf, nil
+}
+
+// miseTool translates a tool, its versions are a string, an array or an
+// inline table with options
+func (v *Config) miseTool(tool string, val interface{}) {
+	// tools of the default and the asdf backends
+	for _, prefix := range []string{"core:", "asdf:"} {
+		tool = strings.TrimPrefix(tool, prefix)
+	}
+	versions := make([]string, 0)
+	values, ok := val.([]interface{})
+	if !ok {
+		values = []interface{}{val}
+	}
+	for _, val := range values {
+		if options, ok := val.(map[string]interface{}); ok {
+			val = options["version"]
+		}
+		version, ok := val.(string)
+		if !ok {
+			v.unmapped("tool '%s' has an unsupported version", tool)
+			return
+		}
+		versions = append(versions, strings.Fields(version)...)
+	}
+	v.tool(tool, versions)
+}
+
+// stringList returns a string or an array of strings as array
+func stringList(val interface{}) ([]string, bool) {
+	switch val := val.(type) {
+	case string:
+		return []string{val}, true
+	case []interface{}:
+		s := make([]string, len(val))
+		for i, e := range val {
+			str, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			s[i] = str
+		}
+		return s, true
+	}
+	return nil, false
+}
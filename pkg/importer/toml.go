@@ -0,0 +1,271 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tomlEntry is a key value pair of a TOML document, keys are dotted
+type tomlEntry struct {
+	Table string
+	Key   string
+	Value interface{}
+}
+
+// tomlParser parses the subset of TOML used by tool manifests: tables, dotted
+// keys, strings, arrays and inline tables. Other values (numbers, dates) are
+// kept as strings.
+type tomlParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func parseTOML(src string) ([]tomlEntry, error) {
+	p := &tomlParser{s: src, line: 1}
+	entries := make([]tomlEntry, 0)
+	table := ""
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return entries, nil
+		}
+		if p.peek() == '[' {
+			p.i++
+			array := !p.eof() && p.peek() == '['
+			if array {
+				p.i++
+			}
+			p.skipSpace(false)
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace(false)
+			closing := "]"
+			if array {
+				closing = "]]"
+			}
+			if !strings.HasPrefix(p.s[p.i:], closing) {
+				return nil, p.errorf("expected %s", closing)
+			}
+			p.i += len(closing)
+			table = key
+		} else {
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace(false)
+			if p.eof() || p.peek() != '=' {
+				return nil, p.errorf("expected = after key '%s'", key)
+			}
+			p.i++
+			p.skipSpace(false)
+			val, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, tomlEntry{Table: table, Key: key, Value: val})
+		}
+		p.skipSpace(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, p.errorf("unexpected '%c'", p.peek())
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.s[p.i]
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments, and newlines if set
+func (p *tomlParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.i++
+		case c == '\n' && newlines:
+			p.line++
+			p.i++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.i++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) key() (string, error) {
+	parts := make([]string, 0, 1)
+	for {
+		if p.eof() {
+			return "", p.errorf("expected key")
+		}
+		switch p.peek() {
+		case '"', '\'':
+			s, err := p.str()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		default:
+			start := p.i
+			for !p.eof() && isBare(p.peek()) {
+				p.i++
+			}
+			if start == p.i {
+				return "", p.errorf("expected key")
+			}
+			parts = append(parts, p.s[start:p.i])
+		}
+		p.skipSpace(false)
+		if p.eof() || p.peek() != '.' {
+			return strings.Join(parts, "."), nil
+		}
+		p.i++
+		p.skipSpace(false)
+	}
+}
+
+func isBare(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) value() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("expected value")
+	}
+	switch p.peek() {
+	case '"', '\'':
+		return p.str()
+	case '[':
+		p.i++
+		arr := make([]interface{}, 0)
+		for {
+			p.skipSpace(true)
+			if p.eof() {
+				return nil, p.errorf("unterminated array")
+			}
+			if p.peek() == ']' {
+				p.i++
+				return arr, nil
+			}
+			val, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+			p.skipSpace(true)
+			if !p.eof() && p.peek() == ',' {
+				p.i++
+			}
+		}
+	case '{':
+		p.i++
+		table := make(map[string]interface{})
+		for {
+			p.skipSpace(false)
+			if p.eof() {
+				return nil, p.errorf("unterminated inline table")
+			}
+			if p.peek() == '}' {
+				p.i++
+				return table, nil
+			}
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if p.eof() || p.peek() != '=' {
+				return nil, p.errorf("expected = after key '%s'", key)
+			}
+			p.i++
+			p.skipSpace(false)
+			val, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			table[key] = val
+			p.skipSpace(false)
+			if !p.eof() && p.peek() == ',' {
+				p.i++
+			}
+		}
+	}
+	start := p.i
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.i++
+	}
+	switch token := p.s[start:p.i]; token {
+	case "":
+		return nil, p.errorf("expected value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return token, nil
+	}
+}
+
+// str parses basic, literal and multi-line strings
+func (p *tomlParser) str() (string, error) {
+	quote := p.s[p.i : p.i+1]
+	if strings.HasPrefix(p.s[p.i:], strings.Repeat(quote, 3)) {
+		delim := strings.Repeat(quote, 3)
+		p.i += 3
+		// a newline following the opening delimiter is trimmed
+		if strings.HasPrefix(p.s[p.i:], "\n") {
+			p.line++
+			p.i++
+		}
+		end := strings.Index(p.s[p.i:], delim)
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		s := p.s[p.i : p.i+end]
+		p.line += strings.Count(s, "\n")
+		p.i += end + 3
+		if quote == "'" {
+			return s, nil
+		}
+		return unescape(s), nil
+	}
+	p.i++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		p.i++
+		switch {
+		case c == quote[0]:
+			if quote == "'" {
+				return b.String(), nil
+			}
+			return unescape(b.String()), nil
+		case c == '\\' && quote == `"` && !p.eof():
+			b.WriteByte(c)
+			b.WriteByte(p.peek())
+			p.i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\n`, "\n", `\t`, "\t", `\r`, "\r").Replace(s)
+}
@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []tomlEntry
+	}{
+		{
+			name: "tables and comments",
+			src: `# tools
+[tools]
+node = "20" # lts
+go = '1.21.3'
+
+[env]
+FOO = "bar"
+`,
+			want: []tomlEntry{
+				{"tools", "node", "20"},
+				{"tools", "go", "1.21.3"},
+				{"env", "FOO", "bar"},
+			},
+		},
+		{
+			name: "dotted and quoted keys",
+			src: `[tools]
+"core:node" = "20"
+tasks.build.run = "make"
+`,
+			want: []tomlEntry{
+				{"tools", "core:node", "20"},
+				{"tools", "tasks.build.run", "make"},
+			},
+		},
+		{
+			name: "arrays with trailing commas",
+			src: `[tools]
+node = [
+  "20", # default
+  "18",
+]
+`,
+			want: []tomlEntry{
+				{"tools", "node", []interface{}{"20", "18"}},
+			},
+		},
+		{
+			name: "inline tables",
+			src: `[tools]
+java = { version = "17", distribution = 'temurin' }
+`,
+			want: []tomlEntry{
+				{"tools", "java", map[string]interface{}{"version": "17", "distribution": "temurin"}},
+			},
+		},
+		{
+			name: "escaped quotes",
+			src: `[hooks]
+postinstall = "echo \"done\" \\ ok"
+literal = 'C:\path'
+`,
+			want: []tomlEntry{
+				{"hooks", "postinstall", `echo "done" \ ok`},
+				{"hooks", "literal", `C:\path`},
+			},
+		},
+		{
+			name: "multi-line strings",
+			src: `[hooks]
+postinstall = """
+npm install
+npm run build"""
+`,
+			want: []tomlEntry{
+				{"hooks", "postinstall", "npm install\nnpm run build"},
+			},
+		},
+		{
+			name: "other values",
+			src: `min_version = 2024.1.0
+experimental = true
+[[tasks]]
+jobs = 4
+`,
+			want: []tomlEntry{
+				{"", "min_version", "2024.1.0"},
+				{"", "experimental", true},
+				{"tasks", "jobs", "4"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := parseTOML(test.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, test.want) {
+				t.Errorf("parseTOML() = %#v, want %#v", entries, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, src := range []string{
+		"[tools\n",
+		"node\n",
+		"node = \"20\n",
+		"node = [\"20\"",
+		"node = { version = \"20\"",
+		"node = \"20\" go\n",
+		"node =\n",
+	} {
+		if _, err := parseTOML(src); err == nil {
+			t.Errorf("parseTOML(%q) succeeded", src)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package importer
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// ToolVersions translates an asdf .tool-versions file
+// (https://asdf-vm.com/manage/configuration.html#tool-versions)
+func ToolVersions(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := new(Config)
+	for _, line := range strings.Split(string(b), "\n") {
+		if n := strings.Index(line, "#"); n >= 0 {
+			line = line[:n]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		conf.tool(fields[0], fields[1:])
+	}
+	return conf, nil
+}
@@ -0,0 +1,68 @@
+package lockfile
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"gopkg.in/yaml.v2"
+)
+
+// Lockfile records the exact versions resolved for a workspace
+type Lockfile struct {
+	path       string
+	Extensions map[string]*Entry `yaml:"extensions"`
+}
+
+// Entry is the locked version of an extension
+type Entry struct {
+	Constraint string `yaml:"constraint"`
+	Version    string `yaml:"version"`
+}
+
+// Get returns the locked version if it was resolved from the same constraint
+func (v *Lockfile) Get(name, constraint string) (string, bool) {
+	entry, ok := v.Extensions[name]
+	if !ok || entry.Constraint != constraint || len(entry.Version) == 0 {
+		return "", false
+	}
+	return entry.Version, true
+}
+
+// Set locks the version of an extension
+func (v *Lockfile) Set(name, constraint, version string) {
+	v.Extensions[name] = &Entry{
+		Constraint: constraint,
+		Version:    version,
+	}
+}
+
+// Save writes the lockfile to disk
+func (v *Lockfile) Save() error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(v.path, b)
+}
+
+// Load reads the lockfile, a missing lockfile results in an empty one
+func Load(path string) (*Lockfile, error) {
+	lock := &Lockfile{
+		path:       path,
+		Extensions: make(map[string]*Entry),
+	}
+	dat, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(dat, lock); err != nil {
+		return nil, err
+	}
+	if lock.Extensions == nil {
+		lock.Extensions = make(map[string]*Entry)
+	}
+	return lock, nil
+}
@@ -0,0 +1,87 @@
+// Package prompt renders the prompt and terminal title of workspace shells
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Modes of prompts
+const (
+	// Replace replaces the prompt of the user
+	Replace = "replace"
+	// Prepend prepends the prompt to the prompt of the user, which is kept
+	// when it is rendered by prompt frameworks
+	Prepend = "prepend"
+)
+
+// DefaultTemplate is the prompt of workspaces without prompt template
+const DefaultTemplate = "({{.Namespace}}) $ "
+
+// variables passing the prompt to the startup files of shells, the startup
+// files unset them
+const (
+	promptKey = "DEM_PROMPT"
+	titleKey  = "DEM_TITLE"
+	modeKey   = "DEM_PROMPT_MODE"
+)
+
+// name of the shell function printing the git branch of the working directory
+const branchFunction = "__dem_branch"
+
+// Data of prompt templates
+type Data struct {
+	Namespace string
+	// Toolchains describe the extensions, e.g `go 1.21.3`
+	Toolchains []string
+	// Versions are the versions of the toolchains by extension name
+	Versions map[string]string
+}
+
+// NewData returns the data of prompt templates of the workspace, the
+// toolchains are the descriptions of its extensions
+func NewData(namespace string, toolchains []string) *Data {
+	versions := make(map[string]string)
+	for _, s := range toolchains {
+		if parts := strings.SplitN(s, " ", 2); len(parts) == 2 {
+			versions[parts[0]] = parts[1]
+		}
+	}
+	return &Data{Namespace: namespace, Toolchains: toolchains, Versions: versions}
+}
+
+// quote quotes a string for POSIX shells
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Render renders a template. The git branch function `branch` takes an
+// optional printf format, e.g `{{branch " on %s"}}`, which is only printed in
+// git repositories. If dynamic is set the branch is a command substitution
+// evaluated by the shell on each prompt, otherwise it is left out.
+func Render(text string, data *Data, dynamic bool) (string, error) {
+	funcs := template.FuncMap{
+		"branch": func(format ...string) string {
+			if !dynamic {
+				return ""
+			}
+			f := "%s"
+			if len(format) > 0 {
+				f = format[0]
+			}
+			return fmt.Sprintf("$(%s %s)", branchFunction, quote(f))
+		},
+		"join": strings.Join,
+	}
+	tmpl, err := template.New("prompt").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,36 @@
+package prompt
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	data := NewData("ws", []string{"go 1.21.3", "node 18.18.2", "binary"})
+	tests := []struct {
+		template string
+		dynamic  bool
+		want     string
+	}{
+		{DefaultTemplate, true, "(ws) $ "},
+		{`{{.Versions.go}} {{index .Versions "node"}}`, false, "1.21.3 18.18.2"},
+		{`[{{join .Toolchains ", "}}]`, false, "[go 1.21.3, node 18.18.2, binary]"},
+		{`{{.Namespace}}{{branch}}`, false, "ws"},
+		{`{{.Namespace}}{{branch}}`, true, "ws$(__dem_branch '%s')"},
+		{`{{branch "it's %s"}}`, true, `$(__dem_branch 'it'\''s %s')`},
+	}
+	for _, test := range tests {
+		got, err := Render(test.template, data, test.dynamic)
+		if err != nil {
+			t.Fatalf("Render(%q) failed, %v", test.template, err)
+		}
+		if got != test.want {
+			t.Errorf("Render(%q, dynamic=%v) = %q, want %q", test.template, test.dynamic, got, test.want)
+		}
+	}
+}
+
+func TestRenderInvalid(t *testing.T) {
+	for _, template := range []string{"{{.Namespace", "{{.Missing}}", "{{branch 1}}"} {
+		if _, err := Render(template, NewData("ws", nil), true); err == nil {
+			t.Errorf("Render(%q) succeeded, want error", template)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Shell is the rendered prompt of a shell session
+type Shell struct {
+	Prompt string
+	// Plain is the prompt without git branch, it is the PS1 of shells
+	// without startup files
+	Plain string
+	// Title of the terminal, it is not set if empty
+	Title string
+	Mode  string
+}
+
+// Vars returns the environment variables passing the prompt to the startup
+// files of shells
+func (v *Shell) Vars() map[string]string {
+	return map[string]string{
+		promptKey: v.Prompt,
+		titleKey:  v.Title,
+		modeKey:   v.Mode,
+	}
+}
+
+// FromEnv returns the prompt passed in the environment of a shell, it returns
+// nil if there is none
+func FromEnv(env map[string]string) *Shell {
+	p, ok := env[promptKey]
+	if !ok {
+		return nil
+	}
+	return &Shell{Prompt: p, Title: env[titleKey], Mode: env[modeKey]}
+}
+
+// Strip returns a copy of the environment without the prompt variables, they
+// must not be exported by startup files as their command substitutions would
+// be evaluated
+func Strip(env map[string]string) map[string]string {
+	stripped := make(map[string]string, len(env))
+	for key, val := range env {
+		if key != promptKey && key != titleKey && key != modeKey {
+			stripped[key] = val
+		}
+	}
+	return stripped
+}
+
+// functions returns the shell code defining the git branch function and the
+// prompt variable, the title is set by the escape sequence wrapped by start
+// and end which mark it as non-printing
+func (v *Shell) functions(start, end string) string {
+	ps1 := v.Prompt
+	if len(v.Title) > 0 {
+		ps1 = start + "\x1b]0;" + v.Title + "\a" + end + ps1
+	}
+	return fmt.Sprintf(`%s() {
+  __dem_ref=$(git symbolic-ref --short HEAD 2>/dev/null || git rev-parse --short HEAD 2>/dev/null) || return 0
+  printf "$1" "$__dem_ref"
+}
+unset %s %s %s
+__dem_ps1=%s
+`, branchFunction, promptKey, titleKey, modeKey, quote(ps1))
+}
+
+// update returns the body of the function updating the prompt
+func (v *Shell) update() string {
+	if v.Mode == Prepend {
+		return `case "$PS1" in "$__dem_ps1"*) ;; *) PS1="$__dem_ps1$PS1" ;; esac`
+	}
+	return `PS1="$__dem_ps1"`
+}
+
+// Bash returns the startup file code setting the prompt, the prompt is
+// updated before each prompt as prompt frameworks render it on each prompt
+func (v *Shell) Bash() string {
+	var b strings.Builder
+	b.WriteString(v.functions(`\[`, `\]`))
+	fmt.Fprintf(&b, "__dem_prompt() { %s; }\n", v.update())
+	b.WriteString(`PROMPT_COMMAND="${PROMPT_COMMAND:+$PROMPT_COMMAND;}__dem_prompt"` + "\n")
+	return b.String()
+}
+
+// Zsh returns the startup file code setting the prompt, see Bash
+func (v *Shell) Zsh() string {
+	var b strings.Builder
+	b.WriteString("setopt PROMPT_SUBST\n")
+	b.WriteString(v.functions("%{", "%}"))
+	fmt.Fprintf(&b, "__dem_prompt() { %s; }\n", v.update())
+	b.WriteString("typeset -ag precmd_functions\nprecmd_functions+=(__dem_prompt)\n")
+	return b.String()
+}
+
+// Sh returns the startup file code setting the prompt once, sh has no prompt
+// hooks
+func (v *Shell) Sh() string {
+	return v.functions("", "") + v.update() + "\n"
+}
+
+// branchPattern matches the git branch command substitutions of rendered
+// prompts, the format is quoted for POSIX shells
+var branchPattern = regexp.MustCompile(`\$\(` + branchFunction + ` '((?:[^']|'\\'')*)'\)`)
+
+// part of a prompt, it is either literal text or a git branch format
+type part struct {
+	text   string
+	branch bool
+}
+
+// split splits a rendered prompt into literal text and git branch formats,
+// for shells without command substitutions in prompt strings
+func split(s string) []part {
+	var parts []part
+	last := 0
+	for _, m := range branchPattern.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > last {
+			parts = append(parts, part{text: s[last:m[0]]})
+		}
+		format := strings.Replace(s[m[2]:m[3]], `'\''`, "'", -1)
+		parts = append(parts, part{text: format, branch: true})
+		last = m[1]
+	}
+	if last < len(s) {
+		parts = append(parts, part{text: s[last:]})
+	}
+	return parts
+}
+
+// nuQuote quotes a string for nushell, raw strings cannot contain single
+// quotes
+func nuQuote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\x1b", `\e`, "\a", `\a`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// nuExpr returns the nushell expression of a rendered prompt
+func nuExpr(s string) string {
+	items := make([]string, 0, 4)
+	for _, p := range split(s) {
+		if p.branch {
+			items = append(items, fmt.Sprintf("(%s %s)", branchFunction, nuQuote(p.text)))
+		} else {
+			items = append(items, nuQuote(p.text))
+		}
+	}
+	return "([" + strings.Join(items, " ") + "] | str join)"
+}
+
+// Nu returns the config file code setting the prompt, nushell evaluates the
+// PROMPT_COMMAND closure on each prompt. It must follow the config of the
+// user.
+func (v *Shell) Nu() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `def %s [format: string] {
+  try {
+    mut ref = (do { ^git symbolic-ref --short HEAD } | complete)
+    if $ref.exit_code != 0 { $ref = (do { ^git rev-parse --short HEAD } | complete) }
+    if $ref.exit_code == 0 { $format | str replace '%%s' ($ref.stdout | str trim) } else { "" }
+  } catch { "" }
+}
+hide-env -i %s %s %s
+`, branchFunction, promptKey, titleKey, modeKey)
+	if v.Mode == Prepend {
+		b.WriteString("let __dem_previous = ($env.PROMPT_COMMAND? | default '')\n")
+		fmt.Fprintf(&b, "$env.PROMPT_COMMAND = {|| %s + (if ($__dem_previous | describe) == 'closure' { do $__dem_previous } else { $__dem_previous }) }\n", nuExpr(v.Prompt))
+	} else {
+		fmt.Fprintf(&b, "$env.PROMPT_COMMAND = {|| %s }\n", nuExpr(v.Prompt))
+		b.WriteString("$env.PROMPT_COMMAND_RIGHT = ''\n$env.PROMPT_INDICATOR = ''\n")
+	}
+	if len(v.Title) > 0 {
+		fmt.Fprintf(&b, "$env.config.hooks.pre_prompt = ($env.config.hooks.pre_prompt? | default [] | append {|| print -n ((char esc) + ']0;' + %s + (char bel)) })\n", nuExpr(v.Title))
+	}
+	return b.String()
+}
+
+// pwshQuote quotes a string for PowerShell
+func pwshQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// pwshExpr returns the PowerShell expression of a rendered prompt
+func pwshExpr(s string) string {
+	items := make([]string, 0, 4)
+	for _, p := range split(s) {
+		if p.branch {
+			items = append(items, fmt.Sprintf("(%s %s)", branchFunction, pwshQuote(p.text)))
+		} else {
+			items = append(items, pwshQuote(p.text))
+		}
+	}
+	return "(-join @(" + strings.Join(items, ", ") + "))"
+}
+
+// Pwsh returns the profile code setting the prompt, PowerShell calls the
+// prompt function on each prompt. It must follow the profile of the user.
+func (v *Shell) Pwsh() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `function global:%s([string]$format) {
+  if (-not (Get-Command git -ErrorAction SilentlyContinue)) { return '' }
+  $ref = git symbolic-ref --short HEAD 2>$null
+  if (-not $ref) { $ref = git rev-parse --short HEAD 2>$null }
+  if ($ref) { $format.Replace('%%s', $ref) } else { '' }
+}
+Remove-Item Env:%s, Env:%s, Env:%s -ErrorAction SilentlyContinue
+`, branchFunction, promptKey, titleKey, modeKey)
+	var title string
+	if len(v.Title) > 0 {
+		title = fmt.Sprintf("$Host.UI.RawUI.WindowTitle = %s; ", pwshExpr(v.Title))
+	}
+	if v.Mode == Prepend {
+		b.WriteString("$global:__dem_previous = $function:prompt\n")
+		fmt.Fprintf(&b, "function global:prompt { %s%s + (& $global:__dem_previous) }\n", title, pwshExpr(v.Prompt))
+	} else {
+		fmt.Fprintf(&b, "function global:prompt { %s%s }\n", title, pwshExpr(v.Prompt))
+	}
+	return b.String()
+}
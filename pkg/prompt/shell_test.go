@@ -0,0 +1,65 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []part
+	}{
+		{"({{.Namespace}}) $ ", []part{{text: "(ws) $ "}}},
+		{"{{branch}}", []part{{text: "%s", branch: true}}},
+		{"{{.Namespace}}{{branch \" on %s\"}} $ ", []part{{text: "ws"}, {text: " on %s", branch: true}, {text: " $ "}}},
+		{"{{branch \"it's %s\"}}{{branch \"[%s]\"}}", []part{{text: "it's %s", branch: true}, {text: "[%s]", branch: true}}},
+		{"$(echo) {{branch}}", []part{{text: "$(echo) "}, {text: "%s", branch: true}}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		s, err := Render(test.template, NewData("ws", nil), true)
+		if err != nil {
+			t.Fatalf("Render(%q) failed, %v", test.template, err)
+		}
+		if got := split(s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("split(%q) = %+v, want %+v", s, got, test.want)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	shell := &Shell{Prompt: "(ws) $ ", Title: "ws", Mode: Prepend}
+	env := shell.Vars()
+	env["PATH"] = "/bin"
+	if got := FromEnv(env); !reflect.DeepEqual(got, shell) {
+		t.Errorf("FromEnv = %+v, want %+v", got, shell)
+	}
+	stripped := Strip(env)
+	if want := map[string]string{"PATH": "/bin"}; !reflect.DeepEqual(stripped, want) {
+		t.Errorf("Strip = %v, want %v", stripped, want)
+	}
+	if got := FromEnv(stripped); got != nil {
+		t.Errorf("FromEnv without prompt = %+v, want nil", got)
+	}
+}
+
+func TestExpr(t *testing.T) {
+	s, err := Render(`{{.Namespace}}{{branch " on %s"}} $ `, NewData("it's", nil), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		expr func(string) string
+		want string
+	}{
+		{"nu", nuExpr, `(["it's" (__dem_branch ' on %s') ' $ '] | str join)`},
+		{"pwsh", pwshExpr, `(-join @('it''s', (__dem_branch ' on %s'), ' $ '))`},
+	}
+	for _, test := range tests {
+		if got := test.expr(s); got != test.want {
+			t.Errorf("%s expression = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
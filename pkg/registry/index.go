@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/samuelngs/dem/pkg/resolver"
+	"gopkg.in/yaml.v2"
+)
+
+// Example of a plugin index:
+//
+// plugins:
+//   go:
+//     - version: 1.0.0
+//       artifacts:
+//         linux/amd64:
+//           url: go-1.0.0-linux-amd64.so # relative to the index
+//           sha256: 9f86d081884c7d65...
+
+// Index lists the released versions of extensions
+type Index struct {
+	location string
+	Plugins  map[string][]*Release `yaml:"plugins"`
+}
+
+// Release is a version of an extension
+type Release struct {
+	Version string `yaml:"version"`
+	// artifacts by platform, e.g linux/amd64
+	Artifacts map[string]*Artifact `yaml:"artifacts"`
+}
+
+// Artifact is a build of an extension module for a single platform
+type Artifact struct {
+	URL    string `yaml:"url"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// Platform is the platform of the artifacts compatible with this build
+var Platform = fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// read reads a local file or fetches an http url
+func read(location string) ([]byte, error) {
+	if !isURL(location) {
+		return ioutil.ReadFile(location)
+	}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return nil, fmt.Errorf("(%d) unable to fetch %s", resp.StatusCode, location)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Fetch reads the plugin index from a local path or an http url
+func Fetch(location string) (*Index, error) {
+	if len(location) == 0 {
+		return nil, fmt.Errorf("no plugin index configured, set plugin_index or use --index")
+	}
+	dat, err := read(location)
+	if err != nil {
+		return nil, err
+	}
+	index := &Index{location: location}
+	if err := yaml.Unmarshal(dat, index); err != nil {
+		return nil, fmt.Errorf("unable to parse plugin index, %v", err)
+	}
+	return index, nil
+}
+
+// Location returns the local path or url the index was read from
+func (v *Index) Location() string {
+	return v.location
+}
+
+// resolve returns the location of an artifact, relative urls are resolved
+// against the location of the index
+func (v *Index) resolve(ref string) (string, error) {
+	if isURL(ref) || filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	if !isURL(v.location) {
+		return filepath.Join(filepath.Dir(v.location), ref), nil
+	}
+	base, err := url.Parse(v.location)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// Find returns the release of an extension and its artifact for this
+// platform. An empty version selects the newest release with an artifact for
+// this platform.
+func (v *Index) Find(name, version string) (*Release, *Artifact, error) {
+	releases, ok := v.Plugins[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("extension '%s' is not in the plugin index", name)
+	}
+	var found *Release
+	for _, release := range releases {
+		if _, ok := release.Artifacts[Platform]; !ok {
+			continue
+		}
+		if release.Version == version {
+			found = release
+			break
+		}
+		if len(version) == 0 && (found == nil || resolver.Compare(release.Version, found.Version) > 0) {
+			found = release
+		}
+	}
+	if found == nil && len(version) > 0 {
+		return nil, nil, fmt.Errorf("extension %s %s is not available for %s", name, version, Platform)
+	} else if found == nil {
+		return nil, nil, fmt.Errorf("extension %s is not available for %s", name, Platform)
+	}
+	artifact := *found.Artifacts[Platform]
+	location, err := v.resolve(artifact.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	artifact.URL = location
+	return found, &artifact, nil
+}
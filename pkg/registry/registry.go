@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"gopkg.in/yaml.v2"
+)
+
+// Registry records the extensions installed from a plugin index
+type Registry struct {
+	dir     string
+	Plugins map[string]*Entry `yaml:"plugins"`
+}
+
+// Entry is an installed extension
+type Entry struct {
+	Version string `yaml:"version"`
+	SHA256  string `yaml:"sha256"`
+	Index   string `yaml:"index"`
+}
+
+func (v *Registry) path() string {
+	return filepath.Join(v.dir, "registry.yaml")
+}
+
+// Save writes the registry to the plugins directory
+func (v *Registry) Save() error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(v.path(), b)
+}
+
+// ModulePath returns the path of an extension module
+func (v *Registry) ModulePath(name string) string {
+	return filepath.Join(v.dir, name+".so")
+}
+
+// Install downloads the artifact of an extension release, verifies its
+// checksum and replaces the installed module. The artifact is staged in a
+// private temporary directory and only opened once its checksum matched.
+func (v *Registry) Install(index *Index, name string, release *Release, artifact *Artifact) error {
+	expected := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(artifact.SHA256), "sha256:"))
+	if len(expected) == 0 {
+		return fmt.Errorf("extension %s %s has no checksum in the plugin index", name, release.Version)
+	}
+	staging, err := ioutil.TempDir("", "dem-plugin-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	staged := filepath.Join(staging, name+".so")
+	f, err := os.OpenFile(staged, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	checksum, err := fetch(artifact.URL, f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if checksum != expected {
+		return fmt.Errorf("checksum mismatch for %s %s, expected %s but got %s", name, release.Version, expected, checksum)
+	}
+	// modules built with a different go version or dependencies fail to open
+	if _, err := ext.Open(staged); err != nil {
+		return fmt.Errorf("extension %s %s is not compatible with this build of dem, %v", name, release.Version, err)
+	}
+	if err := v.replace(name, staged); err != nil {
+		return err
+	}
+	v.Plugins[name] = &Entry{
+		Version: release.Version,
+		SHA256:  checksum,
+		Index:   index.Location(),
+	}
+	return v.Save()
+}
+
+// replace copies a module into the plugins directory under a name which is
+// not loaded as module and renames it over the installed one
+func (v *Registry) replace(name, path string) error {
+	if err := fs.Mkdir(v.dir); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(v.dir, "."+name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := fs.Copy(path, tmp.Name()); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), v.ModulePath(name))
+}
+
+// Remove deletes an installed extension module
+func (v *Registry) Remove(name string) error {
+	path := v.ModulePath(name)
+	if !fs.Exists(path) {
+		return fmt.Errorf("extension '%s' is not installed", name)
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	delete(v.Plugins, name)
+	return v.Save()
+}
+
+// fetch copies a local file or an http url to w and returns its sha256
+// checksum
+func fetch(location string, w io.Writer) (string, error) {
+	var r io.Reader
+	if isURL(location) {
+		resp, err := client.Get(location)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+			return "", fmt.Errorf("(%d) unable to download %s", resp.StatusCode, location)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(location)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), r); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Load reads the registry of the plugins directory, a missing registry results
+// in an empty one
+func Load(pluginsDir string) (*Registry, error) {
+	registry := &Registry{
+		dir:     pluginsDir,
+		Plugins: make(map[string]*Entry),
+	}
+	dat, err := ioutil.ReadFile(registry.path())
+	if os.IsNotExist(err) {
+		return registry, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(dat, registry); err != nil {
+		return nil, err
+	}
+	if registry.Plugins == nil {
+		registry.Plugins = make(map[string]*Entry)
+	}
+	return registry, nil
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallRejected(t *testing.T) {
+	dir := t.TempDir()
+	artifact := filepath.Join(dir, "go.so")
+	body := []byte("not a go plugin")
+	if err := ioutil.WriteFile(artifact, body, 0644); err != nil {
+		t.Fatal(err)
+	}
+	checksum := fmt.Sprintf("%x", sha256.Sum256(body))
+
+	tests := []struct {
+		name   string
+		sha256 string
+		err    string
+	}{
+		{"no checksum", "", "no checksum"},
+		{"mismatch", strings.Repeat("0", 64), "checksum mismatch"},
+		{"incompatible", "sha256:" + strings.ToUpper(checksum), "not compatible"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			staging := t.TempDir()
+			t.Setenv("TMPDIR", staging)
+			pluginsDir := filepath.Join(t.TempDir(), "plugins")
+			reg, err := Load(pluginsDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			index := &Index{location: filepath.Join(dir, "index.yaml")}
+			release := &Release{Version: "1.0.0"}
+			err = reg.Install(index, "go", release, &Artifact{URL: artifact, SHA256: test.sha256})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("err = %v, want %q", err, test.err)
+			}
+			// nothing is written into the plugins directory
+			if files, _ := ioutil.ReadDir(pluginsDir); len(files) > 0 {
+				t.Errorf("plugins directory contains %s", files[0].Name())
+			}
+			if files, _ := ioutil.ReadDir(staging); len(files) > 0 {
+				t.Errorf("staging directory %s was left", files[0].Name())
+			}
+			if _, ok := reg.Plugins["go"]; ok {
+				t.Errorf("rejected extension was recorded")
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package resolver
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Release is a single release listed in an upstream release index
+type Release struct {
+	Version string
+	Stable  bool
+	LTS     bool
+}
+
+// Index describes where and how to read the releases of an ecosystem
+type Index struct {
+	Name  string
+	URL   string
+	Parse func([]byte) ([]Release, error)
+}
+
+// Go release index
+var Go = &Index{
+	Name: "go",
+	URL:  "https://go.dev/dl/?mode=json&include=all",
+	Parse: func(b []byte) ([]Release, error) {
+		var entries []struct {
+			Version string `json:"version"`
+			Stable  bool   `json:"stable"`
+		}
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, err
+		}
+		releases := make([]Release, len(entries))
+		for i, entry := range entries {
+			releases[i] = Release{
+				Version: strings.TrimPrefix(entry.Version, "go"),
+				Stable:  entry.Stable,
+			}
+		}
+		return releases, nil
+	},
+}
+
+// Node release index
+var Node = &Index{
+	Name: "node",
+	URL:  "https://nodejs.org/dist/index.json",
+	Parse: func(b []byte) ([]Release, error) {
+		var entries []struct {
+			Version string      `json:"version"`
+			LTS     interface{} `json:"lts"`
+		}
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, err
+		}
+		releases := make([]Release, len(entries))
+		for i, entry := range entries {
+			lts, _ := entry.LTS.(string)
+			releases[i] = Release{
+				Version: strings.TrimPrefix(entry.Version, "v"),
+				Stable:  true,
+				LTS:     len(lts) > 0,
+			}
+		}
+		return releases, nil
+	},
+}
@@ -0,0 +1,201 @@
+package resolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samuelngs/dem/pkg/lockfile"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// Resolver resolves version constraints against upstream release indexes.
+// Indexes are cached on disk for the duration of TTL.
+type Resolver struct {
+	mu       sync.Mutex
+	CacheDir string
+	TTL      time.Duration
+	Client   *http.Client
+	releases map[string][]Release
+}
+
+// releasesOf returns the releases of the index from memory, disk cache or
+// the upstream index in that order
+func (v *Resolver) releasesOf(index *Index) ([]Release, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if releases, ok := v.releases[index.URL]; ok {
+		return releases, nil
+	}
+
+	var (
+		cachePath = filepath.Join(v.CacheDir, fmt.Sprintf("%s-index.json", index.Name))
+		dat       []byte
+	)
+	if info, err := os.Stat(cachePath); err == nil && len(v.CacheDir) > 0 && time.Since(info.ModTime()) < v.TTL {
+		dat, _ = ioutil.ReadFile(cachePath)
+	}
+	if len(dat) == 0 {
+		resp, err := v.Client.Get(index.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+			return nil, fmt.Errorf("(%d) unable to fetch %s release index", resp.StatusCode, index.Name)
+		}
+		if dat, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+		if len(v.CacheDir) > 0 && fs.Mkdir(v.CacheDir) == nil {
+			fs.WriteFile(cachePath, dat)
+		}
+	}
+
+	releases, err := index.Parse(dat)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s release index, %v", index.Name, err)
+	}
+	v.releases[index.URL] = releases
+	return releases, nil
+}
+
+// Resolve returns the newest release version satisfying the constraint. The
+// constraint can be an exact version (1.21.3 or go 1.20), a partial version
+// (1.21.x), a range (^18, ~1.21) or one of latest, stable and lts.
+func (v *Resolver) Resolve(index *Index, constraint string) (string, error) {
+	exact, err := v.exact(index, constraint)
+	if err != nil {
+		return "", err
+	} else if exact {
+		return strings.TrimSpace(constraint), nil
+	}
+	return v.newest(index, constraint)
+}
+
+// exact reports whether the constraint is an exact version. A bare major or
+// major.minor version is exact if the index has a release of that name (e.g
+// go 1.20), otherwise it is partial (go 1.21 is 1.21.x).
+func (v *Resolver) exact(index *Index, constraint string) (bool, error) {
+	c := strings.TrimSpace(constraint)
+	if IsExact(c) {
+		return true, nil
+	}
+	if !short.MatchString(c) {
+		return false, nil
+	}
+	releases, err := v.releasesOf(index)
+	if err != nil {
+		return false, err
+	}
+	for _, release := range releases {
+		if release.Version == c {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newest returns the newest release version satisfying all of the constraints,
+// exact versions are not supported
+func (v *Resolver) newest(index *Index, constraints ...string) (string, error) {
+	matchers := make([]func(*Release, *version) bool, len(constraints))
+	for i, constraint := range constraints {
+		match, ok := matcher(constraint)
+		if !ok {
+			return "", fmt.Errorf("invalid %s version '%s'", index.Name, constraint)
+		}
+		matchers[i] = match
+	}
+	releases, err := v.releasesOf(index)
+	if err != nil {
+		return "", err
+	}
+	match := func(release *Release, ver *version) bool {
+		for _, m := range matchers {
+			if !m(release, ver) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var (
+		resolved string
+		newest   *version
+	)
+	for i := range releases {
+		release := &releases[i]
+		ver, ok := parse(release.Version)
+		if !ok || !match(release, ver) {
+			continue
+		}
+		if newest == nil || newest.less(ver) {
+			newest = ver
+			resolved = release.Version
+		}
+	}
+	if newest == nil {
+		return "", fmt.Errorf("no %s release matches '%s'", index.Name, strings.Join(constraints, "' and '"))
+	}
+	return resolved, nil
+}
+
+// New creates a resolver caching release indexes in given directory
+func New(cacheDir string) *Resolver {
+	return &Resolver{
+		CacheDir: cacheDir,
+		TTL:      24 * time.Hour,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		releases: make(map[string][]Release),
+	}
+}
+
+// Locked returns the version of a constraint without a lookup of the release
+// index, which is either the exact version or the version locked in the
+// workspace lockfile. It returns false if the constraint requires a Lookup.
+func Locked(config *workspaceconfig.Config, index *Index, constraint string) (string, bool, error) {
+	if IsExact(constraint) {
+		return constraint, true, nil
+	}
+	lock, err := lockfile.Load(config.LockfilePath)
+	if err != nil {
+		return "", false, err
+	}
+	version, ok := lock.Get(index.Name, constraint)
+	return version, ok, nil
+}
+
+// Lookup resolves the version constraint of an extension. Versions locked in
+// the workspace lockfile take precedence, newly resolved versions are written
+// back to the lockfile.
+func Lookup(config *workspaceconfig.Config, index *Index, constraint string) (string, error) {
+	if IsExact(constraint) {
+		return constraint, nil
+	}
+	lock, err := lockfile.Load(config.LockfilePath)
+	if err != nil {
+		return "", err
+	}
+	if version, ok := lock.Get(index.Name, constraint); ok {
+		return version, nil
+	}
+	version, err := New(config.CacheDir).Resolve(index, constraint)
+	if err != nil {
+		return "", err
+	}
+	lock.Set(index.Name, constraint, version)
+	if len(config.LockfilePath) > 0 {
+		if err := lock.Save(); err != nil {
+			return "", err
+		}
+	}
+	return version, nil
+}
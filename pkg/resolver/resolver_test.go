@@ -0,0 +1,207 @@
+package resolver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+const goIndex = `[
+	{"version": "go1.22rc1", "stable": false},
+	{"version": "go1.21.3", "stable": true},
+	{"version": "go1.21.0", "stable": true},
+	{"version": "go1.20.10", "stable": true},
+	{"version": "go1.20", "stable": true},
+	{"version": "go1.19.13", "stable": true}
+]`
+
+const nodeIndex = `[
+	{"version": "v21.1.0", "lts": false},
+	{"version": "v20.9.0", "lts": "Iron"},
+	{"version": "v18.18.2", "lts": "Hydrogen"},
+	{"version": "v18.0.0", "lts": false}
+]`
+
+// serve overrides the URL of the index with a test server, it returns the
+// number of requests to the server
+func serve(t *testing.T, index *Index, body string) *int32 {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, body)
+	}))
+	url := index.URL
+	index.URL = srv.URL
+	t.Cleanup(func() {
+		index.URL = url
+		srv.Close()
+	})
+	return &requests
+}
+
+func TestResolve(t *testing.T) {
+	serve(t, Go, goIndex)
+	serve(t, Node, nodeIndex)
+	tests := []struct {
+		index      *Index
+		constraint string
+		want       string
+		err        string
+	}{
+		{Go, "1.21", "1.21.3", ""},
+		{Go, "1.20", "1.20", ""},
+		{Go, "1", "1.21.3", ""},
+		{Go, "1.21.3", "1.21.3", ""},
+		{Go, "1.20.x", "1.20.10", ""},
+		{Go, "1.x", "1.21.3", ""},
+		{Go, "~1.20", "1.20.10", ""},
+		{Go, "^1.19", "1.21.3", ""},
+		{Go, "latest", "1.21.3", ""},
+		{Go, "stable", "1.21.3", ""},
+		{Go, "1.18.x", "", "no go release matches '1.18.x'"},
+		{Go, "^x", "", "invalid go version '^x'"},
+		{Node, "lts", "20.9.0", ""},
+		{Node, "latest", "21.1.0", ""},
+		{Node, "18.x", "18.18.2", ""},
+		{Node, "^18", "18.18.2", ""},
+		{Node, "18", "18.18.2", ""},
+		{Node, "20", "20.9.0", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.index.Name+" "+test.constraint, func(t *testing.T) {
+			got, err := New("").Resolve(test.index, test.constraint)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("err = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("Resolve(%s) = %s, want %s", test.constraint, got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	requests := serve(t, Go, goIndex)
+	cacheDir := t.TempDir()
+
+	r := New(cacheDir)
+	for _, constraint := range []string{"latest", "~1.20"} {
+		if _, err := r.Resolve(Go, constraint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Fatalf("%d requests, the index is not kept in memory", n)
+	}
+	// a new resolver reads the index from disk
+	if _, err := New(cacheDir).Resolve(Go, "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Fatalf("%d requests, the index is not cached on disk", n)
+	}
+	// an expired index is fetched again
+	expired := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(cacheDir, "go-index.json"), expired, expired)
+	if _, err := New(cacheDir).Resolve(Go, "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("%d requests, the expired index is not fetched", n)
+	}
+	// exact versions do not require the index
+	if _, err := New("").Resolve(Go, "1.19.13"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("%d requests, the index is fetched for an exact version", n)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	requests := serve(t, Go, goIndex)
+	dir := t.TempDir()
+	config := &workspaceconfig.Config{CacheDir: filepath.Join(dir, ".cache"), LockfilePath: filepath.Join(dir, ".workspace.lock")}
+
+	if _, ok, err := Locked(config, Go, "^1.20"); ok || err != nil {
+		t.Fatalf("range is locked before the lookup, %v", err)
+	}
+	if version, ok, _ := Locked(config, Go, "1.20.10"); !ok || version != "1.20.10" {
+		t.Fatalf("exact version is not locked, %s", version)
+	}
+	// a bare major.minor version is resolved
+	if _, ok, _ := Locked(config, Go, "1.21"); ok {
+		t.Fatal("major.minor version is locked before the lookup")
+	}
+	if _, err := os.Stat(config.LockfilePath); !os.IsNotExist(err) {
+		t.Fatal("lockfile is written without a lookup")
+	}
+	version, err := Lookup(config, Go, "^1.20")
+	if err != nil || version != "1.21.3" {
+		t.Fatalf("Lookup = %s, %v", version, err)
+	}
+	if version, ok, err := Locked(config, Go, "^1.20"); !ok || err != nil || version != "1.21.3" {
+		t.Fatalf("Locked = %s, %v, %v", version, ok, err)
+	}
+	// another constraint is resolved again
+	if version, _ := Lookup(config, Go, "~1.20"); version != "1.20.10" {
+		t.Fatalf("Lookup(~1.20) = %s", version)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Fatalf("%d requests, the cache directory is not used", n)
+	}
+}
+
+func TestIsExact(t *testing.T) {
+	tests := map[string]bool{
+		"1.20":    false,
+		"1.21.3":  true,
+		"18":      false,
+		"1.21.x":  false,
+		"18.x":    false,
+		"^18":     false,
+		"~1.21":   false,
+		"latest":  false,
+		"stable":  false,
+		"lts":     false,
+		" lts ":   false,
+		"3.2-dev": true,
+	}
+	for constraint, want := range tests {
+		if got := IsExact(constraint); got != want {
+			t.Errorf("IsExact(%q) = %v, want %v", constraint, got, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.20", "1.20.0", 0},
+		{"1.20.10", "1.20.9", 1},
+		{"1.22rc1", "1.22.0", -1},
+		{"stable", "1.0", strings.Compare("stable", "1.0")},
+	}
+	for _, test := range tests {
+		if got := Compare(test.a, test.b); got != test.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/samuelngs/dem/pkg/lockfile"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// Policy limits how far a version is upgraded
+type Policy string
+
+// Upgrade policies
+const (
+	Patch Policy = "patch" // 1.21.3 to the newest 1.21.x
+	Minor Policy = "minor" // 1.21.3 to the newest 1.x
+	Major Policy = "major" // 1.21.3 to the newest stable release
+)
+
+// ParsePolicy validates the name of an upgrade policy
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case Patch, Minor, Major:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid upgrade policy '%s' (patch, minor, major)", s)
+	}
+}
+
+// constraint returns the range of versions the policy allows to upgrade to
+func (p Policy) constraint(current string) string {
+	switch p {
+	case Patch:
+		return "~" + current
+	case Minor:
+		return "^" + current
+	default:
+		return "stable"
+	}
+}
+
+// Upgrade is the newest version available for a configured version
+type Upgrade struct {
+	// Name of the release index, it is also the key of the extension in the
+	// `with` section of the workspace configuration
+	Name       string
+	Constraint string
+	Current    string
+	Latest     string
+	// the constraint is an exact version
+	exact bool
+}
+
+// Available reports whether the latest version is newer than the current one
+func (v *Upgrade) Available() bool {
+	return Compare(v.Latest, v.Current) > 0
+}
+
+// Pinned reports whether the configuration contains an exact version, which
+// has to be rewritten to upgrade. Ranges are upgraded in the lockfile.
+func (v *Upgrade) Pinned() bool {
+	return v.exact
+}
+
+// Check looks up the newest release for a configured version constraint. An
+// exact version is upgraded as far as the policy allows, a range is resolved
+// again without the lockfile and limited by the policy as well.
+func Check(config *workspaceconfig.Config, index *Index, constraint, current string, policy Policy) (*Upgrade, error) {
+	resolver := New(config.CacheDir)
+	exact, err := resolver.exact(index, constraint)
+	if err != nil {
+		return nil, err
+	}
+	var constraints []string
+	if !exact {
+		constraints = append(constraints, constraint)
+	}
+	// a range which was not resolved yet has no version to limit
+	if len(current) > 0 {
+		constraints = append(constraints, policy.constraint(current))
+	}
+	latest, err := resolver.newest(index, constraints...)
+	if err != nil {
+		return nil, err
+	}
+	return &Upgrade{
+		Name:       index.Name,
+		Constraint: constraint,
+		Current:    current,
+		Latest:     latest,
+		exact:      exact,
+	}, nil
+}
+
+// Lock pins the upgraded version of a range in the workspace lockfile
+func Lock(config *workspaceconfig.Config, upgrade *Upgrade) error {
+	lock, err := lockfile.Load(config.LockfilePath)
+	if err != nil {
+		return err
+	}
+	lock.Set(upgrade.Name, upgrade.Constraint, upgrade.Latest)
+	return lock.Save()
+}
@@ -0,0 +1,43 @@
+package resolver
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+func TestCheck(t *testing.T) {
+	serve(t, Go, goIndex)
+	config := &workspaceconfig.Config{CacheDir: filepath.Join(t.TempDir(), ".cache")}
+	tests := []struct {
+		constraint string
+		current    string
+		policy     Policy
+		want       string
+		pinned     bool
+	}{
+		{"1.20", "1.20", Patch, "1.20.10", true},
+		{"1.20", "1.20", Minor, "1.21.3", true},
+		{"1.19.13", "1.19.13", Major, "1.21.3", true},
+		// ranges are limited by the policy as well
+		{"^1.19", "1.19.13", Patch, "1.19.13", false},
+		{"^1.19", "1.19.13", Minor, "1.21.3", false},
+		{"stable", "1.20", Patch, "1.20.10", false},
+		{"stable", "1.20", Major, "1.21.3", false},
+		// go 1.21 has no release of that name, it is partial
+		{"1.21", "1.21.0", Major, "1.21.3", false},
+		// a range which was not resolved yet
+		{"1.21.x", "", Patch, "1.21.3", false},
+	}
+	for _, test := range tests {
+		upgrade, err := Check(config, Go, test.constraint, test.current, test.policy)
+		if err != nil {
+			t.Errorf("Check(%s, %s, %s) failed, %v", test.constraint, test.current, test.policy, err)
+			continue
+		}
+		if upgrade.Latest != test.want || upgrade.Pinned() != test.pinned {
+			t.Errorf("Check(%s, %s, %s) = %s (pinned %v), want %s (pinned %v)", test.constraint, test.current, test.policy, upgrade.Latest, upgrade.Pinned(), test.want, test.pinned)
+		}
+	}
+}
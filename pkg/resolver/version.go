@@ -0,0 +1,132 @@
+package resolver
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	numeric = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?(.*)$`)
+	partial = regexp.MustCompile(`^\d+(\.\d+)?\.x$`)
+	// bare major or major.minor version, e.g 18 or 1.21
+	short = regexp.MustCompile(`^\d+(\.\d+)?$`)
+)
+
+// version is a parsed release version, missing minor or patch numbers are
+// treated as zero (e.g go 1.20 is 1.20.0)
+type version struct {
+	parts      [3]int
+	prerelease bool
+}
+
+func parse(s string) (*version, bool) {
+	m := numeric.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	v := &version{prerelease: len(m[4]) > 0}
+	for i := 0; i < 3; i++ {
+		if len(m[i+1]) > 0 {
+			v.parts[i], _ = strconv.Atoi(m[i+1])
+		}
+	}
+	return v, true
+}
+
+func (v *version) less(o *version) bool {
+	for i := 0; i < 3; i++ {
+		if v.parts[i] != o.parts[i] {
+			return v.parts[i] < o.parts[i]
+		}
+	}
+	return v.prerelease && !o.prerelease
+}
+
+// IsExact reports whether the constraint is an exact version which does not
+// require a lookup of the release index. A bare major or major.minor version
+// requires a lookup, it is only exact if the index has a release of that name
+// (see Resolve).
+func IsExact(constraint string) bool {
+	switch c := strings.TrimSpace(constraint); {
+	case c == "latest", c == "stable", c == "lts":
+		return false
+	case strings.HasPrefix(c, "^"), strings.HasPrefix(c, "~"):
+		return false
+	case partial.MatchString(c), short.MatchString(c):
+		return false
+	default:
+		return true
+	}
+}
+
+// matcher returns a function reporting whether a release satisfies the
+// constraint
+func matcher(constraint string) (func(*Release, *version) bool, bool) {
+	c := strings.TrimSpace(constraint)
+	switch {
+	case c == "latest", c == "stable":
+		return func(r *Release, v *version) bool {
+			return r.Stable && !v.prerelease
+		}, true
+	case c == "lts":
+		return func(r *Release, v *version) bool {
+			return r.LTS && !v.prerelease
+		}, true
+	}
+
+	operator := c[:1]
+	if operator == "^" || operator == "~" {
+		c = c[1:]
+	} else if partial.MatchString(c) || short.MatchString(c) {
+		operator, c = "", strings.TrimSuffix(c, ".x")
+	} else {
+		return nil, false
+	}
+	base, ok := parse(c)
+	if !ok || base.prerelease {
+		return nil, false
+	}
+	depth := strings.Count(c, ".") + 1
+
+	return func(r *Release, v *version) bool {
+		if !r.Stable || v.prerelease || v.less(base) {
+			return false
+		}
+		switch operator {
+		case "^":
+			// same major version (or minor for 0.x releases)
+			if base.parts[0] == 0 {
+				return v.parts[0] == 0 && v.parts[1] == base.parts[1]
+			}
+			return v.parts[0] == base.parts[0]
+		case "~":
+			return v.parts[0] == base.parts[0] && v.parts[1] == base.parts[1]
+		default:
+			// partial version, e.g 1.21.x matches any 1.21 release
+			for i := 0; i < depth; i++ {
+				if v.parts[i] != base.parts[i] {
+					return false
+				}
+			}
+			return true
+		}
+	}, true
+}
+
+// Compare compares two release versions numerically, it returns -1 if a is
+// older than b, 1 if a is newer and 0 otherwise
+func Compare(a, b string) int {
+	va, okA := parse(a)
+	vb, okB := parse(b)
+	switch {
+	case !okA || !okB:
+		return strings.Compare(a, b)
+	case va.less(vb):
+		return -1
+	case vb.less(va):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"text/template"
 
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/shell/options"
 	"github.com/samuelngs/dem/pkg/util/exec"
 	"github.com/samuelngs/dem/pkg/util/fs"
 )
@@ -15,9 +18,10 @@ var bashrc, _ = template.New("bashrc").Parse(`
 {{- $aliases := .Aliases -}}
 {{- $sources := .Sources -}}
 {{- $envvars := .EnvironmentVariables -}}
+{{- $dotfiles := .Dotfiles -}}
 
-if [ -f "{{$homedir}}/.bashrc" ]; then
-  source {{$homedir}}/.bashrc
+if [ -f "{{$dotfiles}}/.bashrc" ]; then
+  source {{$dotfiles}}/.bashrc
 fi
 
 if [ ! -z "{{$extension_bin}}" ]; then
@@ -35,23 +39,25 @@ source {{$source}}
 {{- range $key, $value := $envvars}}
 export {{$key}}="{{$value}}"
 {{end}}
-`)
 
-type options struct {
-	Home                 string
-	ExtensionBin         string
-	Aliases              map[string]string
-	Sources              []string
-	EnvironmentVariables map[string]string
-}
+{{- if .Prompt}}
+{{.Prompt}}
+{{- end}}
+`)
 
 type bash struct {
 	exec.Command
+	dotfiles *dotfiles.Dotfiles
 }
 
-// Bash accepts a --rcfile filename option (custom ~/.bashrc). Run function would
-// inject and pass custom run command script to initial interactive shell.
-func (v *bash) Run() error {
+// SetDotfiles sets the dotfiles policy, it implements shell.Dotfiler
+func (v *bash) SetDotfiles(d *dotfiles.Dotfiles) {
+	v.dotfiles = d
+}
+
+// Bash accepts a --rcfile filename option (custom ~/.bashrc). Prepare writes
+// the custom run command script and passes it to the initial interactive shell.
+func (v *bash) Prepare() ([]string, error) {
 	var (
 		b               bytes.Buffer
 		homedir         = v.GetEnv("HOME")
@@ -59,18 +65,19 @@ func (v *bash) Run() error {
 		bashrcPath      = filepath.Join(dotdir, ".bashrc")
 		sudoWarningPath = filepath.Join(homedir, ".sudo_as_admin_successful")
 	)
-	opts := &options{
-		Home:                 homedir,
-		ExtensionBin:         v.GetEnv("EXT_PATH"),
-		Aliases:              v.GetAliases(),
-		Sources:              v.GetSources(),
-		EnvironmentVariables: v.GetEnvs(),
+	opts, err := options.New(v.Command, v.dotfiles, ".workspace_shell", ".sudo_as_admin_successful")
+	if err != nil {
+		return nil, err
+	}
+	opts.Warn(v.GetStderr())
+	if p := prompt.FromEnv(v.GetEnvs()); p != nil {
+		opts.Prompt = p.Bash()
 	}
 
 	// write bash startup files
 	fs.Mkdir(dotdir)
 	if err := bashrc.Execute(&b, opts); err != nil {
-		return err
+		return nil, err
 	}
 	fs.WriteFile(bashrcPath, b.Bytes())
 
@@ -84,10 +91,17 @@ func (v *bash) Run() error {
 	// override arguments
 	v.Command.SetArgs("--rcfile", bashrcPath)
 
+	return []string{dotdir, sudoWarningPath}, nil
+}
+
+func (v *bash) Run() error {
+	if _, err := v.Prepare(); err != nil {
+		return err
+	}
 	return v.Command.Run()
 }
 
 // New initializes bash version of exec command
 func New(command string, args ...string) exec.Command {
-	return &bash{exec.New(command)}
+	return &bash{Command: exec.New(command)}
 }
@@ -0,0 +1,116 @@
+// Package dotfiles applies the dotfiles policy of workspace shells
+package dotfiles
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// record is the file in the workspace directory next to the startup files
+// of the shells which records the policy its warnings were printed for
+const record = ".workspace_shell/.dotfiles"
+
+// Dotfiles is the dotfiles policy of a shell session
+type Dotfiles struct {
+	// Dir is the directory of the dotfiles sourced by the startup files
+	Dir string
+	// Home is the home of the user
+	Home string
+	// Files are linked or copied from the home of the user into the
+	// workspace directory
+	Files []*workspaceconfig.Dotfile
+}
+
+// New returns the dotfiles of the policy, home is the home of the user and
+// workspace the workspace directory. Shells without policy are isolated.
+func New(conf *workspaceconfig.Dotfiles, home, workspace string) *Dotfiles {
+	v := &Dotfiles{Dir: workspace, Home: home}
+	if conf == nil {
+		return v
+	}
+	if conf.Policy == workspaceconfig.DotfilesInherit {
+		v.Dir = home
+	}
+	v.Files = conf.Files
+	return v
+}
+
+// Apply links or copies the files into the workspace directory. Copies are
+// only made once, so that changes in the workspace are kept, and files of the
+// workspace are never replaced. Reserved files are written by the shell and
+// skipped. Warnings are returned once for each policy.
+func (v *Dotfiles) Apply(workspace string, reserved ...string) ([]string, error) {
+	warnings := make([]string, 0)
+	for _, file := range v.Files {
+		rel := filepath.Clean(file.Path)
+		if filepath.IsAbs(rel) || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("dotfile '%s' is not relative to the home directory", file.Path)
+		}
+		if contains(reserved, rel) {
+			warnings = append(warnings, fmt.Sprintf("dotfile %s is generated by the shell, skipped", rel))
+			continue
+		}
+		src, dest := filepath.Join(v.Home, rel), filepath.Join(workspace, rel)
+		if _, err := os.Lstat(src); os.IsNotExist(err) {
+			warnings = append(warnings, fmt.Sprintf("dotfile %s does not exist, skipped", src))
+			continue
+		}
+		info, err := os.Lstat(dest)
+		if err == nil && info.Mode()&os.ModeSymlink == 0 {
+			if !file.Copy {
+				warnings = append(warnings, fmt.Sprintf("%s exists in the workspace, %s is not linked", dest, src))
+			}
+			continue
+		} else if err == nil {
+			os.Remove(dest)
+		}
+		if err := fs.Mkdir(filepath.Dir(dest)); err != nil {
+			return nil, err
+		}
+		if file.Copy {
+			err = fs.Copy(src, dest)
+		} else {
+			err = os.Symlink(src, dest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to apply dotfile %s, %v", rel, err)
+		}
+	}
+	path, sum := filepath.Join(workspace, record), v.checksum()
+	if b, err := ioutil.ReadFile(path); err == nil && string(b) == sum {
+		return nil, nil
+	}
+	if err := fs.Mkdir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := fs.WriteFile(path, []byte(sum)); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// checksum identifies the policy
+func (v *Dotfiles) checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", v.Dir, v.Home)
+	for _, file := range v.Files {
+		fmt.Fprintf(h, "%s\x00%t\x00", file.Path, file.Copy)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package dotfiles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// apply applies the dotfiles and returns the warnings
+func apply(t *testing.T, d *Dotfiles, workspace string) string {
+	t.Helper()
+	warnings, err := d.Apply(workspace, ".workspace_shell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(warnings, "\n")
+}
+
+func TestApply(t *testing.T) {
+	var (
+		home      = t.TempDir()
+		workspace = t.TempDir()
+		stow      = filepath.Join(home, "dotfiles", "bashrc")
+	)
+	os.MkdirAll(filepath.Dir(stow), 0755)
+	ioutil.WriteFile(stow, []byte("user\n"), 0644)
+	os.Symlink(stow, filepath.Join(home, ".bashrc"))
+	ioutil.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n"), 0644)
+
+	d := New(&workspaceconfig.Dotfiles{
+		Policy: workspaceconfig.DotfilesIsolated,
+		Files: []*workspaceconfig.Dotfile{
+			{Path: ".bashrc", Copy: true},
+			{Path: ".gitconfig"},
+			{Path: ".missing"},
+		},
+	}, home, workspace)
+
+	warnings := apply(t, d, workspace)
+	if !strings.Contains(warnings, ".missing does not exist") {
+		t.Errorf("missing dotfile is not reported: %q", warnings)
+	}
+
+	// a copy of a linked dotfile is a file, changes are kept in the workspace
+	copied := filepath.Join(workspace, ".bashrc")
+	if info, err := os.Lstat(copied); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("copied dotfile is not a regular file: %v", err)
+	}
+	ioutil.WriteFile(copied, []byte("workspace\n"), 0644)
+	if b, _ := ioutil.ReadFile(stow); string(b) != "user\n" {
+		t.Errorf("editing the copy changed the dotfile of the user: %q", b)
+	}
+	if target, err := os.Readlink(filepath.Join(workspace, ".gitconfig")); err != nil || target != filepath.Join(home, ".gitconfig") {
+		t.Errorf("dotfile is not linked: %s, %v", target, err)
+	}
+
+	// warnings are printed once for each policy
+	if warnings := apply(t, d, workspace); len(warnings) > 0 {
+		t.Errorf("warnings are printed again: %q", warnings)
+	}
+	if b, _ := ioutil.ReadFile(copied); string(b) != "workspace\n" {
+		t.Errorf("copy is replaced: %q", b)
+	}
+	d.Files = append(d.Files, &workspaceconfig.Dotfile{Path: ".inputrc"})
+	if warnings := apply(t, d, workspace); !strings.Contains(warnings, ".missing does not exist") {
+		t.Errorf("warnings are not printed after the policy changed: %q", warnings)
+	}
+}
+
+func TestApplyOutsideHome(t *testing.T) {
+	d := New(&workspaceconfig.Dotfiles{
+		Files: []*workspaceconfig.Dotfile{{Path: "../.bashrc"}},
+	}, t.TempDir(), t.TempDir())
+	if _, err := d.Apply(t.TempDir()); err == nil {
+		t.Error("dotfile outside of the home directory is applied")
+	}
+}
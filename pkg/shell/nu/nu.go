@@ -0,0 +1,153 @@
+package nu
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/shell/options"
+	"github.com/samuelngs/dem/pkg/util/exec"
+	"github.com/samuelngs/dem/pkg/util/fs"
+)
+
+var funcs = template.FuncMap{"quote": quote}
+
+var envnu, _ = template.New("env.nu").Funcs(funcs).Parse(`
+{{- $extension_bin := .ExtensionBin -}}
+{{- $envvars := .EnvironmentVariables -}}
+
+{{- if .Env}}
+source {{quote .Env}}
+{{- end}}
+
+{{- if $extension_bin}}
+$env.PATH = ($env.PATH | split row (char esep) | prepend ({{quote $extension_bin}} | split row (char esep)))
+{{- end}}
+
+{{- range $key, $value := $envvars}}
+$env.{{$key}} = {{quote $value}}
+{{- end}}
+`)
+
+var confignu, _ = template.New("config.nu").Funcs(funcs).Parse(`
+{{- $aliases := .Aliases -}}
+{{- $sources := .Sources -}}
+
+{{- if .Config}}
+source {{quote .Config}}
+{{- end}}
+
+{{- range $alias, $command := $aliases}}
+alias {{$alias}} = {{$command}}
+{{- end}}
+
+{{- range $source := $sources}}
+source {{quote $source}}
+{{- end}}
+
+{{- if .Prompt}}
+{{.Prompt}}
+{{- end}}
+`)
+
+type nuOptions struct {
+	*options.Options
+	// Env and Config are the startup files of the user, nushell resolves
+	// sourced files when it parses the startup files so they are only set
+	// if they exist
+	Env    string
+	Config string
+}
+
+// quote quotes a string for nushell, raw strings cannot contain single quotes
+func quote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// exists returns the path if it is a file
+func exists(path string) string {
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+type nu struct {
+	exec.Command
+	dotfiles *dotfiles.Dotfiles
+}
+
+// SetDotfiles sets the dotfiles policy, it implements shell.Dotfiler
+func (v *nu) SetDotfiles(d *dotfiles.Dotfiles) {
+	v.dotfiles = d
+}
+
+// Nushell accepts --env-config and --config options. Prepare writes both
+// startup files, they source the startup files of the dotfiles directory.
+// Sources which are not nushell scripts are skipped.
+func (v *nu) Prepare() ([]string, error) {
+	var (
+		homedir    = v.GetEnv("HOME")
+		dotdir     = filepath.Join(homedir, ".workspace_shell")
+		envPath    = filepath.Join(dotdir, "env.nu")
+		configPath = filepath.Join(dotdir, "config.nu")
+	)
+	o, err := options.New(v.Command, v.dotfiles, ".workspace_shell")
+	if err != nil {
+		return nil, err
+	}
+	opts := &nuOptions{
+		Options: o,
+		Env:     exists(filepath.Join(o.Dotfiles, ".config", "nushell", "env.nu")),
+		Config:  exists(filepath.Join(o.Dotfiles, ".config", "nushell", "config.nu")),
+	}
+	sources := make([]string, 0, len(o.Sources))
+	for _, source := range o.Sources {
+		if filepath.Ext(source) != ".nu" {
+			o.Warnings = append(o.Warnings, fmt.Sprintf("%s is not a nushell script, skipped", source))
+			continue
+		}
+		sources = append(sources, source)
+	}
+	opts.Sources = sources
+	o.Warn(v.GetStderr())
+	if p := prompt.FromEnv(v.GetEnvs()); p != nil {
+		opts.Prompt = p.Nu()
+	}
+
+	// write nushell startup files
+	fs.Mkdir(dotdir)
+	for path, tmpl := range map[string]*template.Template{envPath: envnu, configPath: confignu} {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, opts); err != nil {
+			return nil, err
+		}
+		fs.WriteFile(path, b.Bytes())
+	}
+
+	// override arguments
+	v.Command.SetArgs("--login", "--env-config", envPath, "--config", configPath)
+
+	return []string{dotdir}, nil
+}
+
+func (v *nu) Run() error {
+	if _, err := v.Prepare(); err != nil {
+		return err
+	}
+	return v.Command.Run()
+}
+
+// New initializes nushell version of exec command
+func New(command string, args ...string) exec.Command {
+	return &nu{Command: exec.New(command)}
+}
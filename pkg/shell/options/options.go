@@ -0,0 +1,58 @@
+// Package options is the data of the startup file templates of shells
+package options
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/util/exec"
+)
+
+// Options of startup files
+type Options struct {
+	Home                 string
+	ExtensionBin         string
+	Aliases              map[string]string
+	Sources              []string
+	EnvironmentVariables map[string]string
+	// Prompt is the shell code setting the prompt, it is set by the shell
+	Prompt string
+	// Dotfiles is the directory of the dotfiles sourced by startup files
+	Dotfiles string
+	// Warnings are the problems which do not prevent the shell from
+	// starting, e.g dotfiles or sources which are skipped
+	Warnings []string
+}
+
+// New returns the options of the command of a shell. The dotfiles policy is
+// applied to the workspace directory, shells without policy are isolated.
+// Reserved files are written by the shell (see dotfiles.Apply).
+func New(cmd exec.Command, d *dotfiles.Dotfiles, reserved ...string) (*Options, error) {
+	homedir := cmd.GetEnv("HOME")
+	if d == nil {
+		d = dotfiles.New(nil, cmd.GetEnv("UNMASK_HOME"), homedir)
+	}
+	warnings, err := d.Apply(homedir, reserved...)
+	if err != nil {
+		return nil, err
+	}
+	return &Options{
+		Home:                 homedir,
+		ExtensionBin:         cmd.GetEnv("EXT_PATH"),
+		Aliases:              cmd.GetAliases(),
+		Sources:              cmd.GetSources(),
+		EnvironmentVariables: prompt.Strip(cmd.GetEnvs()),
+		Dotfiles:             d.Dir,
+		Warnings:             warnings,
+	}, nil
+}
+
+// Warn writes the warnings to w, the shells write them to the stderr of
+// their command
+func (v *Options) Warn(w io.Writer) {
+	for _, warning := range v.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
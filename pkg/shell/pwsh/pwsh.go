@@ -0,0 +1,133 @@
+package pwsh
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/shell/options"
+	"github.com/samuelngs/dem/pkg/util/exec"
+	"github.com/samuelngs/dem/pkg/util/fs"
+)
+
+var profile, _ = template.New("profile").Funcs(template.FuncMap{"quote": quote}).Parse(`
+{{- $extension_bin := .ExtensionBin -}}
+{{- $aliases := .Aliases -}}
+{{- $sources := .Sources -}}
+{{- $envvars := .EnvironmentVariables -}}
+
+{{- range $profile := .Profiles}}
+. {{quote $profile}}
+{{- end}}
+
+{{- if $extension_bin}}
+$env:PATH = {{quote $extension_bin}} + [IO.Path]::PathSeparator + $env:PATH
+{{- end}}
+
+{{- range $alias, $command := $aliases}}
+function global:{{$alias}} { {{$command}} @args }
+{{- end}}
+
+{{- range $source := $sources}}
+. {{quote $source}}
+{{- end}}
+
+{{- range $key, $value := $envvars}}
+$env:{{$key}} = {{quote $value}}
+{{- end}}
+
+if ($env:HISTFILE -and (Get-Command Set-PSReadLineOption -ErrorAction SilentlyContinue)) {
+  Set-PSReadLineOption -HistorySavePath $env:HISTFILE
+}
+
+{{- if .Prompt}}
+{{.Prompt}}
+{{- end}}
+`)
+
+type pwshOptions struct {
+	*options.Options
+	// Profiles are the existing profiles of the dotfiles directory
+	Profiles []string
+}
+
+// quote quotes a string for PowerShell
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+type pwsh struct {
+	exec.Command
+	dotfiles *dotfiles.Dotfiles
+}
+
+// SetDotfiles sets the dotfiles policy, it implements shell.Dotfiler
+func (v *pwsh) SetDotfiles(d *dotfiles.Dotfiles) {
+	v.dotfiles = d
+}
+
+// PowerShell has no option to load a custom profile. Prepare writes the
+// profile, which dot-sources the profiles of the dotfiles directory, and
+// PowerShell runs it without loading its own profiles. Sources which are not
+// PowerShell scripts are skipped.
+func (v *pwsh) Prepare() ([]string, error) {
+	var (
+		b           bytes.Buffer
+		homedir     = v.GetEnv("HOME")
+		dotdir      = filepath.Join(homedir, ".workspace_shell")
+		profilePath = filepath.Join(dotdir, "profile.ps1")
+	)
+	o, err := options.New(v.Command, v.dotfiles, ".workspace_shell")
+	if err != nil {
+		return nil, err
+	}
+	opts := &pwshOptions{Options: o}
+	for _, name := range []string{"profile.ps1", "Microsoft.PowerShell_profile.ps1"} {
+		path := filepath.Join(o.Dotfiles, ".config", "powershell", name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			opts.Profiles = append(opts.Profiles, path)
+		}
+	}
+	sources := make([]string, 0, len(o.Sources))
+	for _, source := range o.Sources {
+		if !strings.EqualFold(filepath.Ext(source), ".ps1") {
+			o.Warnings = append(o.Warnings, fmt.Sprintf("%s is not a PowerShell script, skipped", source))
+			continue
+		}
+		sources = append(sources, source)
+	}
+	opts.Sources = sources
+	o.Warn(v.GetStderr())
+	if p := prompt.FromEnv(v.GetEnvs()); p != nil {
+		opts.Prompt = p.Pwsh()
+	}
+
+	// write PowerShell profile
+	fs.Mkdir(dotdir)
+	if err := profile.Execute(&b, opts); err != nil {
+		return nil, err
+	}
+	fs.WriteFile(profilePath, b.Bytes())
+
+	// override arguments
+	v.Command.SetArgs("-NoLogo", "-NoProfile", "-NoExit", "-Command", ". "+quote(profilePath))
+
+	return []string{dotdir}, nil
+}
+
+func (v *pwsh) Run() error {
+	if _, err := v.Prepare(); err != nil {
+		return err
+	}
+	return v.Command.Run()
+}
+
+// New initializes PowerShell version of exec command
+func New(command string, args ...string) exec.Command {
+	return &pwsh{Command: exec.New(command)}
+}
@@ -2,9 +2,13 @@ package sh
 
 import (
 	"bytes"
+	"os"
 	"path/filepath"
 	"text/template"
 
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/shell/options"
 	"github.com/samuelngs/dem/pkg/util/exec"
 	"github.com/samuelngs/dem/pkg/util/fs"
 )
@@ -15,13 +19,14 @@ var profile, _ = template.New("profile").Parse(`
 {{- $aliases := .Aliases -}}
 {{- $sources := .Sources -}}
 {{- $envvars := .EnvironmentVariables -}}
+{{- $dotfiles := .Dotfiles -}}
 
 if [ "$SHELL" != "/bin/sh" ]; then
   exit 0
 fi
 
-if [ -f "{{$homedir}}/.profile_custom" ]; then
-  source {{$homedir}}/.profile_custom
+if [ -f "{{.Profile}}" ]; then
+  source {{.Profile}}
 fi
 
 if [ ! -z "{{$extension_bin}}" ]; then
@@ -39,47 +44,71 @@ source {{$source}}
 {{- range $key, $value := $envvars}}
 export {{$key}}="{{$value}}"
 {{end}}
+
+{{- if .Prompt}}
+{{.Prompt}}
+{{- end}}
 `)
 
-type options struct {
-	Home                 string
-	ExtensionBin         string
-	Aliases              map[string]string
-	Sources              []string
-	EnvironmentVariables map[string]string
+type shOptions struct {
+	*options.Options
+	Profile string
 }
 
 type bash struct {
 	exec.Command
+	dotfiles *dotfiles.Dotfiles
+}
+
+// SetDotfiles sets the dotfiles policy, it implements shell.Dotfiler
+func (v *bash) SetDotfiles(d *dotfiles.Dotfiles) {
+	v.dotfiles = d
 }
 
 // inject and pass custom run command script to initial interactive shell.
-func (v *bash) Run() error {
+func (v *bash) Prepare() ([]string, error) {
 	var (
 		b           bytes.Buffer
 		homedir     = v.GetEnv("HOME")
 		dotdir      = filepath.Join(homedir, ".workspace_shell")
 		profilePath = filepath.Join(homedir, ".profile")
 	)
-	opts := &options{
-		Home:                 homedir,
-		ExtensionBin:         v.GetEnv("EXT_PATH"),
-		Aliases:              v.GetAliases(),
-		Sources:              v.GetSources(),
-		EnvironmentVariables: v.GetEnvs(),
+	o, err := options.New(v.Command, v.dotfiles, ".profile")
+	if err != nil {
+		return nil, err
+	}
+	o.Warn(v.GetStderr())
+	// the profile of the workspace directory is generated
+	opts := &shOptions{Options: o, Profile: filepath.Join(o.Dotfiles, ".profile")}
+	if o.Dotfiles == homedir {
+		opts.Profile = filepath.Join(homedir, ".profile_custom")
+	}
+	if p := prompt.FromEnv(v.GetEnvs()); p != nil {
+		opts.Prompt = p.Sh()
 	}
 
 	// write sh startup files
 	fs.Mkdir(dotdir)
 	if err := profile.Execute(&b, opts); err != nil {
-		return err
+		return nil, err
+	}
+	// the profile is never written through a link into the home of the user
+	if fs.IsSymlink(profilePath) {
+		os.Remove(profilePath)
 	}
 	fs.WriteFile(profilePath, b.Bytes())
 
+	return []string{profilePath}, nil
+}
+
+func (v *bash) Run() error {
+	if _, err := v.Prepare(); err != nil {
+		return err
+	}
 	return v.Command.Run()
 }
 
 // New initializes sh version of exec command
 func New(command string, args ...string) exec.Command {
-	return &bash{exec.New(command, "-l")}
+	return &bash{Command: exec.New(command, "-l")}
 }
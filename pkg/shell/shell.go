@@ -2,22 +2,56 @@ package shell
 
 import (
 	"path/filepath"
+	"regexp"
 
 	"github.com/samuelngs/dem/pkg/shell/bash"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/shell/nu"
+	"github.com/samuelngs/dem/pkg/shell/pwsh"
 	"github.com/samuelngs/dem/pkg/shell/sh"
 	"github.com/samuelngs/dem/pkg/shell/zsh"
 	"github.com/samuelngs/dem/pkg/util/exec"
 )
 
+// Preparer is implemented by shells which write startup files into the
+// workspace before they start. Prepare returns the paths of the files, it is
+// called by Run.
+type Preparer interface {
+	Prepare() ([]string, error)
+}
+
+// Dotfiler is implemented by shells which source dotfiles, SetDotfiles sets
+// the dotfiles policy applied by Prepare
+type Dotfiler interface {
+	SetDotfiles(*dotfiles.Dotfiles)
+}
+
+// versioned matches versioned names of shell programs, e.g `bash5`,
+// `zsh-5.9` or `pwsh-preview`
+var versioned = regexp.MustCompile(`^([a-z]+?)(?:-?[0-9][0-9.]*)?(?:-(?:preview|lts))?(?:\.exe)?$`)
+
+// name returns the name of the shell of the program
+func name(command string) string {
+	base := filepath.Base(command)
+	if m := versioned.FindStringSubmatch(base); m != nil {
+		return m[1]
+	}
+	return base
+}
+
 // New initializes exec command
 func New(command string, args ...string) exec.Command {
-	switch filepath.Base(command) {
+	switch name(command) {
 	case "zsh":
 		return zsh.New(command, args...)
 	case "bash":
 		return bash.New(command, args...)
 	case "sh":
 		return sh.New(command, args...)
+	case "nu":
+		return nu.New(command, args...)
+	case "pwsh":
+		return pwsh.New(command, args...)
 	default:
 		return exec.New(command, args...)
 	}
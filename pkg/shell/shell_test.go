@@ -0,0 +1,120 @@
+package shell
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"zsh":                  "zsh",
+		"/bin/bash":            "bash",
+		"/usr/local/bin/bash5": "bash",
+		"zsh-5.9":              "zsh",
+		"pwsh-preview":         "pwsh",
+		"pwsh-lts":             "pwsh",
+		"pwsh.exe":             "pwsh",
+		"nu":                   "nu",
+		"sh":                   "sh",
+		"fish":                 "fish",
+		"python3":              "python",
+		"x86_64-tool":          "x86_64-tool",
+		"Bash":                 "Bash",
+	}
+	for command, want := range tests {
+		if got := name(command); got != want {
+			t.Errorf("name(%q) = %s, want %s", command, got, want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]bool{
+		"/bin/zsh":  true,
+		"bash5":     true,
+		"sh":        true,
+		"nu":        true,
+		"pwsh.exe":  true,
+		"/bin/true": false,
+		"python3":   false,
+	}
+	for command, shell := range tests {
+		if _, ok := New(command).(Preparer); ok != shell {
+			t.Errorf("New(%q) is a shell = %v, want %v", command, ok, shell)
+		}
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	tests := []struct {
+		command string
+		file    string
+		args    []string
+		want    []string
+		skipped string
+	}{
+		{
+			"nu",
+			"config.nu",
+			[]string{"--login", "--env-config", ".workspace_shell/env.nu", "--config", ".workspace_shell/config.nu"},
+			[]string{"alias ll = ls -l", "source '/src/env.nu'"},
+			"/src/env.sh",
+		},
+		{
+			"nu",
+			"env.nu",
+			[]string{"--login", "--env-config", ".workspace_shell/env.nu", "--config", ".workspace_shell/config.nu"},
+			[]string{"prepend ('/ext/bin' | split row (char esep))", "$env.GREETING = \"it's\""},
+			"",
+		},
+		{
+			"pwsh-preview",
+			"profile.ps1",
+			[]string{"-NoLogo", "-NoProfile", "-NoExit", "-Command", ". '.workspace_shell/profile.ps1'"},
+			[]string{
+				"$env:PATH = '/ext/bin' + [IO.Path]::PathSeparator + $env:PATH",
+				"function global:ll { ls -l @args }",
+				". '/src/env.PS1'",
+				"$env:GREETING = 'it''s'",
+			},
+			"/src/env.sh",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.command+" "+test.file, func(t *testing.T) {
+			home := t.TempDir()
+			cmd := New(test.command)
+			cmd.SetEnv(map[string]string{"HOME": home, "UNMASK_HOME": t.TempDir(), "EXT_PATH": "/ext/bin", "GREETING": "it's"})
+			cmd.SetAliases(map[string]string{"ll": "ls -l"})
+			cmd.SetSources("/src/env.nu", "/src/env.PS1", "/src/env.sh")
+			var stderr bytes.Buffer
+			cmd.SetStderr(&stderr)
+			if _, err := cmd.(Preparer).Prepare(); err != nil {
+				t.Fatal(err)
+			}
+			if len(test.skipped) > 0 && !strings.Contains(stderr.String(), "warning: "+test.skipped+" is not a") {
+				t.Errorf("skipped source is not reported: %q", stderr.String())
+			}
+			b, err := ioutil.ReadFile(filepath.Join(home, ".workspace_shell", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("%s does not contain %q:\n%s", test.file, want, b)
+				}
+			}
+			if len(test.skipped) > 0 && strings.Contains(string(b), test.skipped) {
+				t.Errorf("%s sources %s", test.file, test.skipped)
+			}
+			args := strings.Join(cmd.GetArgs(), " ")
+			want := strings.Replace(strings.Join(test.args, " "), ".workspace_shell", filepath.Join(home, ".workspace_shell"), -1)
+			if args != want {
+				t.Errorf("args = %s, want %s", args, want)
+			}
+		})
+	}
+}
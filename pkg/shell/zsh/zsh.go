@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"text/template"
 
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/shell/options"
 	"github.com/samuelngs/dem/pkg/util/exec"
 	"github.com/samuelngs/dem/pkg/util/fs"
 )
@@ -15,9 +18,10 @@ var zshrc, _ = template.New("zshrc").Parse(`
 {{- $aliases := .Aliases -}}
 {{- $sources := .Sources -}}
 {{- $envvars := .EnvironmentVariables -}}
+{{- $dotfiles := .Dotfiles -}}
 
-if [ -f "{{$homedir}}/.zshrc" ]; then
-  source {{$homedir}}/.zshrc
+if [ -f "{{$dotfiles}}/.zshrc" ]; then
+  source {{$dotfiles}}/.zshrc
 fi
 
 if [ ! -z "{{$extension_bin}}" ]; then
@@ -35,25 +39,31 @@ source {{$source}}
 {{- range $key, $value := $envvars}}
 export {{$key}}="{{$value}}"
 {{end}}
-`)
 
-type options struct {
-	Home                 string
-	ExtensionBin         string
-	Aliases              map[string]string
-	Sources              []string
-	EnvironmentVariables map[string]string
-}
+# history is only saved with a history size
+: ${HISTSIZE:=10000}
+: ${SAVEHIST:=10000}
+
+{{- if .Prompt}}
+{{.Prompt}}
+{{- end}}
+`)
 
 type zsh struct {
 	exec.Command
+	dotfiles *dotfiles.Dotfiles
+}
+
+// SetDotfiles sets the dotfiles policy, it implements shell.Dotfiler
+func (v *zsh) SetDotfiles(d *dotfiles.Dotfiles) {
+	v.dotfiles = d
 }
 
 // Unlike bash, zsh does not support flag `--init-file`. In order to modify existing
 // $PATH environment variable, and also add command alias(es) to shell, custom zsh
 // dotfiles are used to solve this specific problem. Keep in mind if $HOME/.zshrc
 // exists, the auto generated $ZDOTDIR/.zshrc will also source $HOME/.zshrc file.
-func (v *zsh) Run() error {
+func (v *zsh) Prepare() ([]string, error) {
 	var (
 		b         bytes.Buffer
 		homedir   = v.GetEnv("HOME")
@@ -62,24 +72,25 @@ func (v *zsh) Run() error {
 		symlinks  = []string{".zprofile", ".zshenv", ".zlogin", ".zlogout"}
 	)
 
-	opts := &options{
-		Home:                 homedir,
-		ExtensionBin:         v.GetEnv("EXT_PATH"),
-		Aliases:              v.GetAliases(),
-		Sources:              v.GetSources(),
-		EnvironmentVariables: v.GetEnvs(),
+	opts, err := options.New(v.Command, v.dotfiles, ".workspace_shell")
+	if err != nil {
+		return nil, err
+	}
+	opts.Warn(v.GetStderr())
+	if p := prompt.FromEnv(v.GetEnvs()); p != nil {
+		opts.Prompt = p.Zsh()
 	}
 
 	// write zsh startup files
 	fs.Mkdir(dotdir)
 	for _, file := range symlinks {
-		path := filepath.Join(homedir, file)
+		path := filepath.Join(opts.Dotfiles, file)
 		dest := filepath.Join(dotdir, file)
 		fs.Symlink(path, dest)
 	}
 
 	if err := zshrc.Execute(&b, opts); err != nil {
-		return err
+		return nil, err
 	}
 	fs.WriteFile(zshrcPath, b.Bytes())
 
@@ -88,10 +99,17 @@ func (v *zsh) Run() error {
 		"ZDOTDIR": dotdir,
 	})
 
+	return []string{dotdir}, nil
+}
+
+func (v *zsh) Run() error {
+	if _, err := v.Prepare(); err != nil {
+		return err
+	}
 	return v.Command.Run()
 }
 
 // New initializes zsh version of exec command
 func New(command string, args ...string) exec.Command {
-	return &zsh{exec.New(command, "-l")}
+	return &zsh{Command: exec.New(command, "-l")}
 }
@@ -0,0 +1,144 @@
+package shim
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/samuelngs/dem/pkg/resolver"
+	"github.com/samuelngs/dem/pkg/util/fs"
+)
+
+// The shim walks from the current directory up to the root, reads the
+// version from the first version file found, and executes the command of the
+// newest installed version matching it. Unmatched versions fall back to the
+// default version.
+var script, _ = template.New("shim").Parse(`#!/bin/sh
+# Code generated by dem. DO NOT EDIT.
+
+bin() {
+  case "$1" in
+{{- range $version, $bin := .Versions}}
+    "{{$version}}") echo "{{$bin}}" ;;
+{{- end}}
+  esac
+}
+
+match() {
+  found=""
+  for v in{{range .Sorted}} {{.}}{{end}}; do
+    case "$v" in "$1"|"$1".*) found="$v" ;; esac
+  done
+  echo "$found"
+}
+
+want=""
+source=""
+dir="$PWD"
+while [ -z "$want" ]; do
+{{- range .Files}}
+  if [ -z "$want" ] && [ -f "$dir/{{.Name}}" ]; then
+    want=$(sed -n '{{.Expression}}' "$dir/{{.Name}}" | head -n 1)
+    source="$dir/{{.Name}}"
+  fi
+{{- end}}
+  [ "$dir" = "/" ] && break
+  dir=$(dirname "$dir")
+done
+
+version="{{.Default}}"
+if [ -n "$want" ]; then
+  found=$(match "$want")
+  if [ -n "$found" ]; then
+    version="$found"
+  else
+    echo "dem: {{.Name}} $want ($source) is not installed, using {{.Default}}" >&2
+  fi
+fi
+
+exec "$(bin "$version")/$(basename "$0")" "$@"
+`)
+
+// File is a version file searched in the current directory and its parents
+type File struct {
+	Name string
+	// sed expression printing the version, e.g. `s/^go \([0-9.]*\).*/\1/p`
+	Expression string
+}
+
+// Shim describes the commands of a toolchain installed in multiple versions
+type Shim struct {
+	Name     string
+	Dir      string
+	Commands []string
+	Files    []File
+	// maps installed versions to their bin directory
+	Versions map[string]string
+	Default  string
+}
+
+// Sorted returns the installed versions from oldest to newest
+func (v *Shim) Sorted() []string {
+	versions := make([]string, 0, len(v.Versions))
+	for version := range v.Versions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return resolver.Compare(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// Script renders the shim script shared by all commands
+func (v *Shim) Script() ([]byte, error) {
+	var b bytes.Buffer
+	if err := script.Execute(&b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Outdated checks whether any of the shims is missing or differs from the
+// current configuration
+func (v *Shim) Outdated() bool {
+	b, err := v.Script()
+	if err != nil {
+		return true
+	}
+	for _, command := range v.Commands {
+		dat, err := ioutil.ReadFile(filepath.Join(v.Dir, command))
+		if err != nil || !bytes.Equal(dat, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Write generates the shims of all commands
+func (v *Shim) Write() error {
+	b, err := v.Script()
+	if err != nil {
+		return err
+	}
+	if err := fs.Mkdir(v.Dir); err != nil {
+		return err
+	}
+	for _, command := range v.Commands {
+		if err := ioutil.WriteFile(filepath.Join(v.Dir, command), b, 0755); err != nil {
+			return err
+		}
+		if err := os.Chmod(filepath.Join(v.Dir, command), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToolVersions returns the version file entry of a tool in an asdf
+// .tool-versions file
+func ToolVersions(tool string) File {
+	return File{".tool-versions", `s/^` + tool + ` \([^ ]*\).*/\1/p`}
+}
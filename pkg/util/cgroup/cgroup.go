@@ -0,0 +1,33 @@
+package cgroup
+
+import "errors"
+
+// ErrUnsupported is returned when cgroup v2 is not mounted or the systemd
+// user manager is not available to create cgroups
+var ErrUnsupported = errors.New("cgroup v2 delegation is not available")
+
+// Limits of a cgroup, zero values are left untouched
+type Limits struct {
+	MemoryMax string
+	CPUWeight int
+	CPUQuota  string
+	PidsMax   int
+}
+
+// Stats is the current resource usage of a cgroup
+type Stats struct {
+	MemoryCurrent uint64
+	MemoryMax     string
+	CPUUsage      uint64
+	CPUWeight     string
+	CPUMax        string
+	PidsCurrent   uint64
+	PidsMax       string
+	Procs         int
+}
+
+// Cgroup interface
+type Cgroup interface {
+	Path() string
+	Stats() (*Stats, error)
+}
@@ -0,0 +1,205 @@
+//go:build linux
+// +build linux
+
+package cgroup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cmdexec "github.com/samuelngs/dem/pkg/util/exec"
+	"github.com/samuelngs/dem/pkg/util/fs"
+)
+
+var mountPoint = "/sys/fs/cgroup"
+
+type cgroup struct {
+	path string
+}
+
+func (v *cgroup) Path() string {
+	return v.path
+}
+
+func (v *cgroup) Stats() (*Stats, error) {
+	if !fs.Exists(v.path) {
+		return nil, fmt.Errorf("cgroup %s does not exist", v.path)
+	}
+	stats := &Stats{
+		MemoryCurrent: v.readUint("memory.current"),
+		MemoryMax:     v.read("memory.max"),
+		CPUWeight:     v.read("cpu.weight"),
+		CPUMax:        v.read("cpu.max"),
+		PidsCurrent:   v.readUint("pids.current"),
+		PidsMax:       v.read("pids.max"),
+	}
+	for _, line := range strings.Split(v.read("cpu.stat"), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "usage_usec" {
+			stats.CPUUsage, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	if procs := v.read("cgroup.procs"); len(procs) > 0 {
+		stats.Procs = len(strings.Split(procs, "\n"))
+	}
+	return stats, nil
+}
+
+func (v *cgroup) read(file string) string {
+	b, err := ioutil.ReadFile(filepath.Join(v.path, file))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (v *cgroup) readUint(file string) uint64 {
+	n, _ := strconv.ParseUint(v.read(file), 10, 64)
+	return n
+}
+
+// cpuQuota validates a percentage quota (e.g 150%), max removes the quota
+func cpuQuota(s string) (string, error) {
+	if s == "max" {
+		return "", nil
+	}
+	percentage, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || percentage <= 0 || !strings.HasSuffix(s, "%") {
+		return "", fmt.Errorf("invalid cpu quota '%s'", s)
+	}
+	return s, nil
+}
+
+// properties returns the systemd resource control properties of the limits
+func properties(limits Limits) ([]string, error) {
+	props := make([]string, 0)
+	if s := strings.TrimSpace(limits.MemoryMax); s == "max" {
+		props = append(props, "MemoryMax=infinity")
+	} else if len(s) > 0 {
+		props = append(props, "MemoryMax="+s)
+	}
+	if limits.CPUWeight > 0 {
+		props = append(props, fmt.Sprintf("CPUWeight=%d", limits.CPUWeight))
+	}
+	if s := strings.TrimSpace(limits.CPUQuota); len(s) > 0 {
+		quota, err := cpuQuota(s)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, "CPUQuota="+quota)
+	}
+	if limits.PidsMax > 0 {
+		props = append(props, fmt.Sprintf("TasksMax=%d", limits.PidsMax))
+	}
+	return props, nil
+}
+
+// escape escapes a name for a systemd unit name, a dash would otherwise nest
+// the slice (see systemd-escape)
+func escape(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == ':', c == '_', c == '.' && i > 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	return b.String()
+}
+
+// slice returns the unit of the slice of a workspace, it is nested in the
+// dem.slice of the systemd user manager
+func slice(name string) string {
+	return fmt.Sprintf("dem-%s.slice", escape(name))
+}
+
+// systemctl runs systemctl against the user manager and returns its output
+func systemctl(args ...string) (string, error) {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); len(msg) > 0 {
+			return "", fmt.Errorf("%s: %v", msg, ErrUnsupported)
+		}
+		return "", ErrUnsupported
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// scope runs the command in a transient scope of the slice, the scope is
+// created by systemd-run which then starts the command. Only the command is
+// moved into the cgroup, the current process is not.
+type scope struct {
+	systemdRun string
+	slice      string
+}
+
+// busKeys locate the session bus of the user manager, systemd-run fails
+// without them
+var busKeys = []string{"XDG_RUNTIME_DIR", "DBUS_SESSION_BUS_ADDRESS"}
+
+func (v *scope) Prepare(cmd *exec.Cmd) error {
+	set := make(map[string]bool)
+	for _, kv := range cmd.Env {
+		set[strings.SplitN(kv, "=", 2)[0]] = true
+	}
+	for _, key := range busKeys {
+		if val := os.Getenv(key); len(val) > 0 && !set[key] {
+			cmd.Env = append(cmd.Env, key+"="+val)
+		}
+	}
+	args := []string{v.systemdRun, "--user", "--scope", "--quiet", "--collect", "--slice=" + v.slice, "--", cmd.Path}
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = v.systemdRun
+	return nil
+}
+
+func (v *scope) Start(*os.Process) error {
+	return nil
+}
+
+func (v *scope) Close() error {
+	return nil
+}
+
+// New sets the limits of the slice of given name and returns a wrapper which
+// starts commands in a scope of the slice
+func New(name string, limits Limits) (cmdexec.Wrapper, error) {
+	if !fs.Exists(filepath.Join(mountPoint, "cgroup.controllers")) {
+		return nil, ErrUnsupported
+	}
+	systemdRun, err := exec.LookPath("systemd-run")
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	props, err := properties(limits)
+	if err != nil {
+		return nil, err
+	}
+	unit := slice(name)
+	if len(props) > 0 {
+		if _, err := systemctl(append([]string{"set-property", "--runtime", unit}, props...)...); err != nil {
+			return nil, err
+		}
+	}
+	return &scope{systemdRun, unit}, nil
+}
+
+// Open returns the cgroup of the slice of given name, it exists while
+// commands of the slice are running
+func Open(name string) (Cgroup, error) {
+	path, err := systemctl("show", "--property=ControlGroup", "--value", slice(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cgroup of %s does not exist", slice(name))
+	}
+	return &cgroup{filepath.Join(mountPoint, path)}, nil
+}
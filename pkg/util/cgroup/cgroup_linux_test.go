@@ -0,0 +1,64 @@
+package cgroup
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestSlice(t *testing.T) {
+	tests := map[string]string{
+		"test":        "dem-test.slice",
+		"my-project":  `dem-my\x2dproject.slice`,
+		"v1.2":        "dem-v1.2.slice",
+		"with space":  `dem-with\x20space.slice`,
+		"under_score": "dem-under_score.slice",
+	}
+	for name, want := range tests {
+		if got := slice(name); got != want {
+			t.Errorf("slice(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestProperties(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   []string
+		err    bool
+	}{
+		{Limits{}, []string{}, false},
+		{Limits{MemoryMax: "2G", CPUWeight: 200, CPUQuota: "150%", PidsMax: 512}, []string{"MemoryMax=2G", "CPUWeight=200", "CPUQuota=150%", "TasksMax=512"}, false},
+		{Limits{MemoryMax: "max", CPUQuota: "max"}, []string{"MemoryMax=infinity", "CPUQuota="}, false},
+		{Limits{CPUQuota: "150"}, nil, true},
+		{Limits{CPUQuota: "-5%"}, nil, true},
+	}
+	for _, test := range tests {
+		props, err := properties(test.limits)
+		if (err != nil) != test.err {
+			t.Errorf("properties(%+v) err = %v", test.limits, err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(props, test.want) {
+			t.Errorf("properties(%+v) = %v, want %v", test.limits, props, test.want)
+		}
+	}
+}
+
+func TestScopePrepare(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	cmd := exec.Command("/bin/sh", "-l")
+	cmd.Env = []string{"HOME=/workspace"}
+	wrapper := &scope{"/usr/bin/systemd-run", "dem-test.slice"}
+	if err := wrapper.Prepare(cmd); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/usr/bin/systemd-run", "--user", "--scope", "--quiet", "--collect", "--slice=dem-test.slice", "--", "/bin/sh", "-l"}
+	if cmd.Path != want[0] || !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("command = %s %v, want %v", cmd.Path, cmd.Args, want)
+	}
+	if env := []string{"HOME=/workspace", "XDG_RUNTIME_DIR=/run/user/1000"}; !reflect.DeepEqual(cmd.Env, env) {
+		t.Errorf("env = %v, want %v", cmd.Env, env)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package cgroup
+
+import cmdexec "github.com/samuelngs/dem/pkg/util/exec"
+
+// New sets the limits of the slice of given name and returns a wrapper which
+// starts commands in a scope of the slice
+func New(name string, limits Limits) (cmdexec.Wrapper, error) {
+	return nil, ErrUnsupported
+}
+
+// Open returns the cgroup of the slice of given name, it exists while
+// commands of the slice are running
+func Open(name string) (Cgroup, error) {
+	return nil, ErrUnsupported
+}
@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,7 +13,10 @@ import (
 
 // Downloader interface
 type Downloader interface {
-	Start(chan<- int) error
+	// Start downloads the file and reports the percentage downloaded to the
+	// progress channel, which is closed once the download ends. The file is
+	// written to dest only once it is downloaded completely.
+	Start(context.Context, chan<- int) error
 }
 
 type downloader struct {
@@ -20,53 +24,71 @@ type downloader struct {
 	Dest string
 }
 
-func (v *downloader) Start(progress chan<- int) error {
+func (v *downloader) Start(ctx context.Context, progress chan<- int) error {
+	defer close(progress)
 
-	out, err := os.Create(v.Dest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.URL, nil)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
-
-	done := make(chan int64)
-
-	resp, err := http.Get(v.URL)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	size, err := strconv.Atoi(resp.Header.Get("Content-Length"))
+	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
+		return fmt.Errorf("(%d) unable to download package %s", resp.StatusCode, filepath.Base(v.Dest))
+	}
+
+	// partial downloads are never left at dest
+	part := v.Dest + ".part"
+	out, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(part)
+	defer out.Close()
 
+	size, _ := strconv.Atoi(resp.Header.Get("Content-Length"))
+	done, stopped := make(chan struct{}), make(chan struct{})
+	defer func() {
+		// progress must not be sent to once it is closed
+		close(done)
+		<-stopped
+	}()
 	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-done:
-				break
-			default:
-				f, err := os.Open(v.Dest)
-				if err != nil {
-					return
+				return
+			case <-ticker.C:
+				s, err := out.Stat()
+				if err != nil || size <= 0 {
+					continue
 				}
-				s, err := f.Stat()
-				if err != nil {
+				select {
+				case progress <- int(float64(s.Size()) / float64(size) * 100.0):
+				case <-done:
 					return
 				}
-				percentage := int(float64(s.Size()) / float64(size) * 100.0)
-				progress <- percentage
 			}
-			time.Sleep(100 * time.Millisecond)
 		}
 	}()
 
-	if !(resp.StatusCode >= 200 && resp.StatusCode <= 299) {
-		return fmt.Errorf("(%d) unable to download package %s", resp.StatusCode, filepath.Base(v.Dest))
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
 	}
-
-	n, err := io.Copy(out, resp.Body)
-	done <- n
-
-	return err
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, v.Dest)
 }
 
 // New creates a download manager
@@ -1,15 +1,68 @@
 package exec
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"syscall"
 )
 
+// forwarded signals are relayed to the process group of the command
+var forwarded = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGWINCH, syscall.SIGHUP}
+
+// Wrapper prepares the underlying process before it is started, e.g. to run
+// it inside of namespaces, and releases its resources once the process exits
+type Wrapper interface {
+	Prepare(*exec.Cmd) error
+	Start(*os.Process) error
+	Close() error
+}
+
+// Chain returns a wrapper applying the wrappers in order, each of them wraps
+// the process prepared by the previous ones
+func Chain(wrappers ...Wrapper) Wrapper {
+	return chain(wrappers)
+}
+
+type chain []Wrapper
+
+func (v chain) Prepare(cmd *exec.Cmd) error {
+	for i, wrapper := range v {
+		if err := wrapper.Prepare(cmd); err != nil {
+			v[:i].Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (v chain) Start(process *os.Process) error {
+	for _, wrapper := range v {
+		if err := wrapper.Start(process); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v chain) Close() error {
+	var err error
+	for _, wrapper := range v {
+		if e := wrapper.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
 // Command abstracts over creating command
 type Command interface {
 	Run() error
+	SetContext(context.Context)
+	SetWrapper(Wrapper)
 	SetCommand(string)
 	SetArgs(...string)
 	SetDir(string)
@@ -21,6 +74,7 @@ type Command interface {
 	SetStderr(io.Writer)
 	GetCommand() string
 	GetArgs() []string
+	GetStderr() io.Writer
 	GetEnv(string) string
 	GetEnvs() map[string]string
 	GetAliases() map[string]string
@@ -28,6 +82,7 @@ type Command interface {
 }
 
 type command struct {
+	ctx            context.Context
 	dir            string
 	cmd            string
 	args           []string
@@ -36,6 +91,7 @@ type command struct {
 	sources        []string
 	stdin          io.Reader
 	stdout, stderr io.Writer
+	wrapper        Wrapper
 }
 
 func (v *command) Run() error {
@@ -50,10 +106,79 @@ func (v *command) Run() error {
 		cmd.Env[i] = fmt.Sprintf("%s=%s", key, val)
 		i++
 	}
+
+	// run the command in its own process group, if attached to a terminal the
+	// process group is placed in the foreground so that job control works
+	tty, isTTY := terminal(v.stdin)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:    true,
+		Foreground: isTTY,
+	}
+	if v.wrapper != nil {
+		if err := v.wrapper.Prepare(cmd); err != nil {
+			return err
+		}
+		defer v.wrapper.Close()
+	}
 	if err := cmd.Start(); err != nil {
 		return err
 	}
-	return cmd.Wait()
+	if isTTY {
+		defer foreground(tty)
+	}
+
+	// signals are relayed to the process group and the group is killed once
+	// the context is done, until the command exited. The relay is stopped
+	// before the command is reaped, so that the id of the process group is
+	// never signalled once it may be reused.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwarded...)
+	var (
+		exited  = make(chan struct{})
+		relayed = make(chan struct{})
+	)
+	go func() {
+		defer close(relayed)
+		for {
+			select {
+			case sig := <-signals:
+				syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
+			case <-v.ctx.Done():
+				syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+				return
+			case <-exited:
+				return
+			}
+		}
+	}()
+	wait := func() error {
+		waitExited(cmd.Process.Pid)
+		signal.Stop(signals)
+		close(exited)
+		<-relayed
+		return cmd.Wait()
+	}
+
+	if v.wrapper != nil {
+		if err := v.wrapper.Start(cmd.Process); err != nil {
+			cmd.Process.Kill()
+			wait()
+			return err
+		}
+	}
+	err := wait()
+	if ctxErr := v.ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func (v *command) SetContext(ctx context.Context) {
+	v.ctx = ctx
+}
+
+func (v *command) SetWrapper(wrapper Wrapper) {
+	v.wrapper = wrapper
 }
 
 func (v *command) SetCommand(cmd string) {
@@ -104,6 +229,10 @@ func (v *command) GetArgs() []string {
 	return v.args
 }
 
+func (v *command) GetStderr() io.Writer {
+	return v.stderr
+}
+
 func (v *command) GetEnv(key string) string {
 	return v.envs[key]
 }
@@ -120,9 +249,23 @@ func (v *command) GetSources() []string {
 	return v.sources
 }
 
+// ExitStatus returns the exit status of the process the error originates
+// from. Processes terminated by a signal report 128 + signal number.
+func ExitStatus(err error) (int, bool) {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), true
+	}
+	return exitErr.ExitCode(), true
+}
+
 // New creates abstracted command interface
 func New(cmd string, args ...string) Command {
 	c := &command{
+		ctx:     context.Background(),
 		cmd:     cmd,
 		args:    args,
 		envs:    make(map[string]string),
@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunReleasesRelay(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		cmd := New("/bin/sh", "-c", "exit 0")
+		cmd.SetStdin(nil)
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// goroutines of the runtime may still be winding down
+	time.Sleep(50 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before+2 {
+		t.Fatalf("%d goroutines before and %d after running commands", before, after)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		status  int
+		err     error
+	}{
+		{"success", "exit 0", 0, 0, nil},
+		{"exit status", "exit 3", 0, 3, nil},
+		{"signalled", "kill -TERM $$", 0, 128 + int(syscall.SIGTERM), nil},
+		// the background child keeps the group alive, it is killed as well
+		{"cancelled", "sleep 30 & wait", 100 * time.Millisecond, 0, context.DeadlineExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := New("/bin/sh", "-c", test.script)
+			cmd.SetStdin(nil)
+			ctx := context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+			cmd.SetContext(ctx)
+			start := time.Now()
+			err := cmd.Run()
+			if test.err != nil {
+				if err != test.err {
+					t.Fatalf("err = %v, want %v", err, test.err)
+				}
+				if time.Since(start) > 10*time.Second {
+					t.Fatal("process group was not killed")
+				}
+				return
+			}
+			status, _ := ExitStatus(err)
+			if status != test.status {
+				t.Fatalf("status = %d (%v), want %d", status, err, test.status)
+			}
+		})
+	}
+}
+
+func TestExitStatusOfOtherErrors(t *testing.T) {
+	err := New("/nonexistent/" + strings.Repeat("x", 8)).Run()
+	if _, ok := ExitStatus(err); ok || err == nil {
+		t.Fatalf("ExitStatus(%v) reports an exit status", err)
+	}
+}
+
+// recorder records the calls of a wrapper
+type recorder struct {
+	name  string
+	err   error
+	calls *[]string
+}
+
+func (v *recorder) Prepare(cmd *exec.Cmd) error {
+	*v.calls = append(*v.calls, "prepare "+v.name)
+	return v.err
+}
+
+func (v *recorder) Start(*os.Process) error {
+	*v.calls = append(*v.calls, "start "+v.name)
+	return nil
+}
+
+func (v *recorder) Close() error {
+	*v.calls = append(*v.calls, "close "+v.name)
+	return nil
+}
+
+func TestChain(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name  string
+		err   error
+		calls []string
+	}{
+		{"prepared", nil, []string{"prepare a", "prepare b", "start a", "start b", "close a", "close b"}},
+		{"failed", failed, []string{"prepare a", "prepare b", "close a"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := make([]string, 0)
+			wrapper := Chain(&recorder{"a", nil, &calls}, &recorder{"b", test.err, &calls})
+			if err := wrapper.Prepare(exec.Command("true")); err != test.err {
+				t.Fatalf("Prepare = %v, want %v", err, test.err)
+			}
+			if test.err == nil {
+				wrapper.Start(nil)
+				wrapper.Close()
+			}
+			if !reflect.DeepEqual(calls, test.calls) {
+				t.Errorf("calls = %v, want %v", calls, test.calls)
+			}
+		})
+	}
+}
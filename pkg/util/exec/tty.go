@@ -0,0 +1,30 @@
+package exec
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/mattn/go-isatty"
+)
+
+// terminal returns the file of the reader if it is a terminal
+func terminal(r io.Reader) (*os.File, bool) {
+	f, ok := r.(*os.File)
+	if !ok || f == nil || !isatty.IsTerminal(f.Fd()) {
+		return nil, false
+	}
+	return f, true
+}
+
+// foreground places the process group of dem back in the foreground of the
+// terminal once the command exits
+func foreground(tty *os.File) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	pgrp := int32(syscall.Getpgrp())
+	syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgrp)))
+}
@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package exec
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// waitExited blocks until the process exited without reaping it, its process
+// group id cannot be reused until it is reaped by Wait
+func waitExited(pid int) {
+	const (
+		pPID    = 1
+		wNoWait = 0x1000000
+	)
+	var info [128]byte
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, pPID, uintptr(pid), uintptr(unsafe.Pointer(&info[0])), syscall.WEXITED|wNoWait, 0, 0)
+		if errno != syscall.EINTR {
+			return
+		}
+	}
+}
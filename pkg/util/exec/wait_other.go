@@ -0,0 +1,7 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// waitExited returns immediately, the process is reaped by Wait
+func waitExited(pid int) {}
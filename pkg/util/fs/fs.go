@@ -1,8 +1,10 @@
 package fs
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 // Exists checks if file or directory exists
@@ -54,3 +56,49 @@ func Mkdir(s ...string) error {
 func Rename(oldpath, newpath string) error {
 	return os.Rename(oldpath, newpath)
 }
+
+// Copy copies a file or directory recursively, modes are kept and symbolic
+// links inside of directories are copied as links. If src is a link its
+// target is copied.
+func Copy(src, dest string) error {
+	src, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
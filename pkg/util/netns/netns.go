@@ -0,0 +1,37 @@
+package netns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// helperKey is the environment variable that marks the re-executed dem
+// process running inside of the network namespace, its value is the file
+// descriptor of the control socket
+var helperKey = "DEM_NETNS_HELPER"
+
+// Port forwards a host port to a port inside of the namespace
+type Port struct {
+	Host   int
+	Target int
+}
+
+// ParsePort parses port in format of `port` or `host:target`
+func ParsePort(s string) (Port, error) {
+	var (
+		parts = strings.SplitN(strings.TrimSpace(s), ":", 2)
+		port  Port
+		err   error
+	)
+	if port.Host, err = strconv.Atoi(parts[0]); err != nil {
+		return port, fmt.Errorf("invalid port '%s'", s)
+	}
+	port.Target = port.Host
+	if len(parts) == 2 {
+		if port.Target, err = strconv.Atoi(parts[1]); err != nil {
+			return port, fmt.Errorf("invalid port '%s'", s)
+		}
+	}
+	return port, nil
+}
@@ -0,0 +1,283 @@
+//go:build linux
+// +build linux
+
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	cmdexec "github.com/samuelngs/dem/pkg/util/exec"
+)
+
+const (
+	capNetAdmin          = 12
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+)
+
+// ErrDisabled is returned when unprivileged user namespaces are disabled
+var ErrDisabled = errors.New("unprivileged user namespaces are disabled on this system")
+
+type namespace struct {
+	mu        sync.Mutex
+	ports     []Port
+	listeners []net.Listener
+	control   *net.UnixConn
+	remote    *os.File
+}
+
+// Prepare re-executes dem as a helper inside of new user and network
+// namespaces, the helper brings up the loopback interface and starts the
+// original command. Ports and sockets are released if it fails.
+func (v *namespace) Prepare(cmd *exec.Cmd) (err error) {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			v.Close()
+		}
+	}()
+	for _, port := range v.ports {
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port.Host))
+		if err != nil {
+			return err
+		}
+		v.listeners = append(v.listeners, l)
+	}
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	local := os.NewFile(uintptr(fds[0]), "netns-control")
+	defer local.Close()
+	v.remote = os.NewFile(uintptr(fds[1]), "netns-control")
+	conn, err := net.FileConn(local)
+	if err != nil {
+		return err
+	}
+	v.control = conn.(*net.UnixConn)
+
+	var (
+		uid = os.Getuid()
+		gid = os.Getgid()
+		fd  = 3 + len(cmd.ExtraFiles)
+	)
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%d", helperKey, fd))
+	cmd.ExtraFiles = append(cmd.ExtraFiles, v.remote)
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	attr := cmd.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	attr.AmbientCaps = append(attr.AmbientCaps, capNetAdmin)
+	return nil
+}
+
+// Start forwards the host ports once the helper process is running
+func (v *namespace) Start(*os.Process) error {
+	v.remote.Close()
+	v.remote = nil
+	for i, l := range v.listeners {
+		go v.forward(l, v.ports[i])
+	}
+	return nil
+}
+
+// Close stops listening on the host ports and closes the control sockets
+func (v *namespace) Close() error {
+	for _, l := range v.listeners {
+		l.Close()
+	}
+	v.listeners = nil
+	if v.control != nil {
+		v.control.Close()
+	}
+	if v.remote != nil {
+		v.remote.Close()
+		v.remote = nil
+	}
+	return nil
+}
+
+func (v *namespace) forward(l net.Listener, port Port) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			target, err := v.dial(port.Target)
+			if err != nil {
+				return
+			}
+			defer target.Close()
+			go io.Copy(target, conn)
+			io.Copy(conn, target)
+		}()
+	}
+}
+
+// dial asks the helper to connect to the port inside of the namespace, the
+// connected socket is passed back over the control socket.
+func (v *namespace) dial(port int) (net.Conn, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, err := v.control.Write([]byte(strconv.Itoa(port))); err != nil {
+		return nil, err
+	}
+	var (
+		buf = make([]byte, 256)
+		oob = make([]byte, syscall.CmsgSpace(4))
+	)
+	n, oobn, _, _, err := v.control.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		return nil, fmt.Errorf("unable to connect to port %d: %s", port, buf[:n])
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) == 0 {
+		return nil, fmt.Errorf("unable to connect to port %d", port)
+	}
+	f := os.NewFile(uintptr(fds[0]), "netns-conn")
+	defer f.Close()
+	return net.FileConn(f)
+}
+
+// Supported checks whether unprivileged user namespaces are available
+func Supported() error {
+	for _, path := range []string{"/proc/sys/kernel/unprivileged_userns_clone", "/proc/sys/user/max_user_namespaces"} {
+		if b, err := ioutil.ReadFile(path); err == nil && strings.TrimSpace(string(b)) == "0" {
+			return ErrDisabled
+		}
+	}
+	return nil
+}
+
+// New creates a command wrapper which isolates the network of the process
+func New(ports ...Port) (cmdexec.Wrapper, error) {
+	if err := Supported(); err != nil {
+		return nil, err
+	}
+	return &namespace{ports: ports}, nil
+}
+
+// IsHelper checks if the current process is the namespace helper
+func IsHelper() bool {
+	_, exists := os.LookupEnv(helperKey)
+	return exists
+}
+
+// RunHelper runs the original command inside of the namespace and serves
+// dial requests from the parent process. It returns the exit code of the
+// command.
+func RunHelper() int {
+	fd, err := strconv.Atoi(os.Getenv(helperKey))
+	os.Unsetenv(helperKey)
+	if err != nil || len(os.Args) < 2 {
+		return 1
+	}
+	if err := loopback(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to bring up loopback interface: %v\n", err)
+		return 1
+	}
+
+	// the command should not inherit the network capabilities of the helper
+	syscall.RawSyscall(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0)
+
+	if conn, err := net.FileConn(os.NewFile(uintptr(fd), "netns-control")); err == nil {
+		go serve(conn.(*net.UnixConn))
+	}
+
+	cmd := exec.Command(os.Args[1], os.Args[2:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// dem relays signals to the whole process group, the helper only has to
+	// survive them until the command exits
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	if err := cmd.Wait(); err != nil {
+		if code, ok := cmdexec.ExitStatus(err); ok {
+			return code
+		}
+		return 1
+	}
+	return 0
+}
+
+func serve(conn *net.UnixConn) {
+	defer conn.Close()
+	buf := make([]byte, 16)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil || n == 0 {
+			return
+		}
+		target, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%s", buf[:n]))
+		if err != nil {
+			conn.Write([]byte(err.Error()))
+			continue
+		}
+		f, err := target.(*net.TCPConn).File()
+		target.Close()
+		if err != nil {
+			conn.Write([]byte(err.Error()))
+			continue
+		}
+		conn.WriteMsgUnix([]byte("ok"), syscall.UnixRights(int(f.Fd())), nil)
+		f.Close()
+	}
+}
+
+// loopback brings up the loopback interface of the network namespace
+func loopback() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	var req struct {
+		name  [syscall.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+	copy(req.name[:], "lo")
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return errno
+	}
+	req.flags |= syscall.IFF_UP
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build linux
+// +build linux
+
+package netns
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"testing"
+)
+
+func TestPrepareReleasesPortsOnError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	free := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	v := &namespace{ports: []Port{
+		{Host: free, Target: 80},
+		{Host: busy.Addr().(*net.TCPAddr).Port, Target: 81},
+	}}
+	if err := v.Prepare(exec.Command("true")); err == nil {
+		t.Fatal("prepared with a port in use")
+	}
+	l, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", free))
+	if err != nil {
+		t.Fatalf("port %d is still in use after Prepare failed, %v", free, err)
+	}
+	l.Close()
+	if v.remote != nil {
+		t.Error("control socket is not closed")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package netns
+
+import (
+	"errors"
+
+	cmdexec "github.com/samuelngs/dem/pkg/util/exec"
+)
+
+// ErrDisabled is returned when network namespaces are not available
+var ErrDisabled = errors.New("network namespaces are only supported on linux")
+
+// Supported checks whether unprivileged user namespaces are available
+func Supported() error {
+	return ErrDisabled
+}
+
+// New creates a command wrapper which isolates the network of the process
+func New(ports ...Port) (cmdexec.Wrapper, error) {
+	return nil, ErrDisabled
+}
+
+// IsHelper checks if the current process is the namespace helper
+func IsHelper() bool {
+	return false
+}
+
+// RunHelper runs the original command inside of the namespace
+func RunHelper() int {
+	return 1
+}
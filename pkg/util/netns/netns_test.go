@@ -0,0 +1,22 @@
+package netns
+
+import "testing"
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Port
+		err  bool
+	}{
+		{"8080", Port{8080, 8080}, false},
+		{" 3000:80 ", Port{3000, 80}, false},
+		{"http", Port{}, true},
+		{"80:x", Port{}, true},
+	}
+	for _, test := range tests {
+		got, err := ParsePort(test.in)
+		if (err != nil) != test.err || (!test.err && got != test.want) {
+			t.Errorf("ParsePort(%q) = %v, %v", test.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package unpack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mholt/archiver"
+)
+
+const (
+	// prefix of the temporary directories of extractions in the destination
+	tmpPrefix = ".unpack-"
+	// marker is written into the extracted directories before they are
+	// renamed into dest, see Complete
+	marker = ".unpacked"
+)
+
+// Complete reports whether the directory was extracted by Unarchive, which
+// renames directories into place only once they are complete
+func Complete(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, marker))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Unarchive extracts the archive at source into dest. The archive is extracted
+// into a temporary directory in dest first, next to the entries it replaces,
+// and each of its top level trees is renamed into dest once the extraction
+// completed. A tree of dest with the same name is renamed away before and
+// removed after, so an interrupted extraction never leaves a partial
+// installation behind. Extracted directories are marked as complete (see
+// Complete). The extraction stops once the context is done.
+func Unarchive(ctx context.Context, unarchiver archiver.Unarchiver, source, dest string) error {
+	_, err := UnarchiveTrees(ctx, unarchiver, source, dest)
+	return err
+}
+
+// UnarchiveTrees extracts the archive like Unarchive and returns the names of
+// its top level trees in dest, e.g the versioned directory of a toolchain
+func UnarchiveTrees(ctx context.Context, unarchiver archiver.Unarchiver, source, dest string) ([]string, error) {
+	reader, ok := unarchiver.(archiver.Reader)
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive %s", filepath.Base(source))
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+	// temporary directories of extractions which were killed
+	if stale, err := filepath.Glob(filepath.Join(dest, tmpPrefix+"*")); err == nil {
+		for _, path := range stale {
+			os.RemoveAll(path)
+		}
+	}
+	tmp, err := ioutil.TempDir(dest, tmpPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	extracted := filepath.Join(tmp, "extracted")
+	if err := extract(ctx, reader, source, extracted); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(extracted)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if file.IsDir() {
+			if err := ioutil.WriteFile(filepath.Join(extracted, file.Name(), marker), nil, 0644); err != nil {
+				return nil, err
+			}
+		}
+		path := filepath.Join(dest, file.Name())
+		if _, err := os.Lstat(path); err == nil {
+			if err := os.Rename(path, filepath.Join(tmp, fmt.Sprintf("replaced-%d", i))); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.Rename(filepath.Join(extracted, file.Name()), path); err != nil {
+			return nil, err
+		}
+		names = append(names, file.Name())
+	}
+	return names, nil
+}
+
+// contextReader fails reads once the context is done, so that decompression
+// stops within large entries
+type contextReader struct {
+	ctx context.Context
+	f   *os.File
+}
+
+func (v *contextReader) Read(p []byte) (int, error) {
+	if err := v.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return v.f.Read(p)
+}
+
+func (v *contextReader) ReadAt(p []byte, off int64) (int, error) {
+	if err := v.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return v.f.ReadAt(p, off)
+}
+
+// extract writes the entries of the archive into dest, the context is checked
+// between entries
+func extract(ctx context.Context, reader archiver.Reader, source, dest string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := reader.Open(&contextReader{ctx, f}, info.Size()); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	defer reader.Close()
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+		err = write(dest, file)
+		if file.ReadCloser != nil {
+			file.Close()
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+	}
+}
+
+// write writes an archive entry into dest
+func write(dest string, file archiver.File) error {
+	switch header := file.Header.(type) {
+	case *tar.Header:
+		path, err := target(dest, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			return os.MkdirAll(path, 0755)
+		case tar.TypeReg, tar.TypeRegA:
+			return writeFile(path, file, file.Mode())
+		case tar.TypeSymlink:
+			return symlink(header.Linkname, path)
+		case tar.TypeLink:
+			oldname, err := target(dest, header.Linkname)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			return os.Link(oldname, path)
+		}
+		// devices, fifos and pax headers are not installed
+		return nil
+	case zip.FileHeader:
+		path, err := target(dest, header.Name)
+		if err != nil {
+			return err
+		}
+		switch mode := file.Mode(); {
+		case mode.IsDir():
+			return os.MkdirAll(path, 0755)
+		case mode&os.ModeSymlink != 0:
+			b, err := ioutil.ReadAll(file)
+			if err != nil {
+				return err
+			}
+			return symlink(string(b), path)
+		default:
+			return writeFile(path, file, mode)
+		}
+	default:
+		return fmt.Errorf("unsupported archive entry %s", file.Name())
+	}
+}
+
+// target returns the path of an entry, entries outside of dest are rejected
+func target(dest, name string) (string, error) {
+	path := filepath.Join(dest, name)
+	if path != dest && !strings.HasPrefix(path, dest+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %s is outside of the destination", name)
+	}
+	return path, nil
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func symlink(oldname, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(oldname, path)
+}
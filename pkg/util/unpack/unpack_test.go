@@ -0,0 +1,196 @@
+package unpack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mholt/archiver"
+)
+
+type entry struct {
+	name, body, link string
+	dir, hardlink    bool
+}
+
+var entries = []entry{
+	{name: "go/", dir: true},
+	{name: "go/bin/go", body: "#!/bin/sh\n"},
+	{name: "go/VERSION", body: "go1.20"},
+	{name: "go/bin/gofmt", link: "go"},
+	{name: "go/VERSION.copy", link: "go/VERSION", hardlink: true},
+}
+
+func writeTarGz(t *testing.T, path string, entries []entry) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.dir:
+			header.Typeflag, header.Size = tar.TypeDir, 0
+		case e.hardlink:
+			header.Typeflag, header.Linkname, header.Size = tar.TypeLink, e.link, 0
+		case e.link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.body))
+	}
+	tw.Close()
+	gw.Close()
+}
+
+func writeZip(t *testing.T, path string, entries []entry) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		if e.hardlink {
+			continue
+		}
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		switch {
+		case e.dir:
+			header.SetMode(os.ModeDir | 0755)
+		case e.link != "":
+			header.SetMode(os.ModeSymlink | 0777)
+			e.body = e.link
+		default:
+			header.SetMode(0755)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.body))
+	}
+	zw.Close()
+}
+
+func TestUnarchive(t *testing.T) {
+	tests := []struct {
+		name       string
+		unarchiver archiver.Unarchiver
+		write      func(*testing.T, string, []entry)
+		hardlinks  bool
+	}{
+		{"tar.gz", archiver.NewTarGz(), writeTarGz, true},
+		{"zip", archiver.NewZip(), writeZip, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			source := filepath.Join(dir, "archive")
+			test.write(t, source, entries)
+			dest := filepath.Join(dir, "dest")
+
+			// files of a previous installation are replaced, other trees of
+			// dest are kept
+			os.MkdirAll(filepath.Join(dest, "go", "bin"), 0755)
+			ioutil.WriteFile(filepath.Join(dest, "go", "stale"), nil, 0644)
+			os.MkdirAll(filepath.Join(dest, "other"), 0755)
+
+			trees, err := UnarchiveTrees(context.Background(), test.unarchiver, source, dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trees) != 1 || trees[0] != "go" {
+				t.Errorf("trees = %v, want [go]", trees)
+			}
+			if b, err := ioutil.ReadFile(filepath.Join(dest, "go", "VERSION")); err != nil || string(b) != "go1.20" {
+				t.Errorf("VERSION = %q, %v", b, err)
+			}
+			if info, err := os.Stat(filepath.Join(dest, "go", "bin", "go")); err != nil || info.Mode().Perm() != 0755 {
+				t.Errorf("bin/go = %v, %v", info, err)
+			}
+			if link, err := os.Readlink(filepath.Join(dest, "go", "bin", "gofmt")); err != nil || link != "go" {
+				t.Errorf("bin/gofmt links to %q, %v", link, err)
+			}
+			if test.hardlinks {
+				if b, err := ioutil.ReadFile(filepath.Join(dest, "go", "VERSION.copy")); err != nil || string(b) != "go1.20" {
+					t.Errorf("VERSION.copy = %q, %v", b, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dest, "go", "stale")); !os.IsNotExist(err) {
+				t.Errorf("stale file of the previous installation was kept")
+			}
+			if _, err := os.Stat(filepath.Join(dest, "other")); err != nil {
+				t.Errorf("unrelated tree was removed, %v", err)
+			}
+			if !Complete(filepath.Join(dest, "go")) || Complete(filepath.Join(dest, "other")) {
+				t.Errorf("extracted tree is not marked as complete")
+			}
+			assertNoTemp(t, dest)
+		})
+	}
+}
+
+func TestUnarchiveCancelled(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "archive.tar.gz")
+	writeTarGz(t, source, entries)
+	dest := filepath.Join(dir, "dest")
+	os.MkdirAll(filepath.Join(dest, "go"), 0755)
+	ioutil.WriteFile(filepath.Join(dest, "go", "VERSION"), []byte("go1.19"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Unarchive(ctx, archiver.NewTarGz(), source, dest); err != context.Canceled {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	// the previous installation is untouched
+	if b, _ := ioutil.ReadFile(filepath.Join(dest, "go", "VERSION")); string(b) != "go1.19" {
+		t.Errorf("VERSION = %q, want go1.19", b)
+	}
+	assertNoTemp(t, dest)
+}
+
+func TestUnarchiveOutside(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "archive.tar.gz")
+	writeTarGz(t, source, []entry{{name: "../evil", body: "x"}})
+	dest := filepath.Join(dir, "dest")
+	if err := Unarchive(context.Background(), archiver.NewTarGz(), source, dest); err == nil {
+		t.Fatal("entry outside of the destination was extracted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+		t.Errorf("evil was written")
+	}
+	assertNoTemp(t, dest)
+}
+
+func TestUnarchiveStale(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "archive.tar.gz")
+	writeTarGz(t, source, entries)
+	dest := filepath.Join(dir, "dest")
+	os.MkdirAll(filepath.Join(dest, tmpPrefix+"killed", "go"), 0755)
+	if err := Unarchive(context.Background(), archiver.NewTarGz(), source, dest); err != nil {
+		t.Fatal(err)
+	}
+	assertNoTemp(t, dest)
+}
+
+func assertNoTemp(t *testing.T, dest string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dest, tmpPrefix+"*"))
+	if len(matches) > 0 {
+		t.Errorf("temporary directories were left in dest: %v", matches)
+	}
+}
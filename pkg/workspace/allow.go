@@ -0,0 +1,76 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"gopkg.in/yaml.v2"
+)
+
+// allowedPath is the record of the directories allowed to activate their
+// workspace by the shell hook, it maps directories to workspaces
+func (v *Manager) allowedPath() string {
+	return filepath.Join(v.StorageDir, ".allowed.yaml")
+}
+
+func (v *Manager) allowed() (map[string]string, error) {
+	allowed := make(map[string]string)
+	b, err := ioutil.ReadFile(v.allowedPath())
+	if os.IsNotExist(err) {
+		return allowed, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, &allowed); err != nil {
+		return nil, err
+	}
+	if allowed == nil {
+		allowed = make(map[string]string)
+	}
+	return allowed, nil
+}
+
+// Allowed reports whether the shell hook may set up and activate the
+// workspace of the link. Project directories are allowed by the workspace
+// configuration, directories linked by a marker file have to be allowed.
+func (v *Manager) Allowed(link *Link) bool {
+	if filepath.Base(link.Path) != MarkerFile {
+		return true
+	}
+	allowed, err := v.allowed()
+	return err == nil && allowed[link.Dir] == link.Namespace
+}
+
+// Allow allows the shell hook to set up and activate the workspace of the
+// link, until the marker file names another workspace
+func (v *Manager) Allow(link *Link) error {
+	allowed, err := v.allowed()
+	if err != nil {
+		return err
+	}
+	allowed[link.Dir] = link.Namespace
+	return v.writeAllowed(allowed)
+}
+
+// Deny revokes Allow
+func (v *Manager) Deny(link *Link) error {
+	allowed, err := v.allowed()
+	if err != nil {
+		return err
+	}
+	delete(allowed, link.Dir)
+	return v.writeAllowed(allowed)
+}
+
+func (v *Manager) writeAllowed(allowed map[string]string) error {
+	b, err := yaml.Marshal(allowed)
+	if err != nil {
+		return err
+	}
+	if err := fs.Mkdir(v.StorageDir); err != nil {
+		return err
+	}
+	return fs.WriteFile(v.allowedPath(), b)
+}
@@ -0,0 +1,92 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/samuelngs/dem/pkg/devcontainer"
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/image"
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell"
+	"github.com/samuelngs/dem/pkg/util/netns"
+)
+
+// variables of the environment which only apply to the host
+var hostOnly = []string{"DISPLAY", "UNMASK_HOME"}
+
+// Image returns an image of the workspace with the installed toolchains, and
+// the shell with its startup files as entrypoint. The workspace must be set up.
+func (v *Workspace) Image(base, tag string) (*image.Image, error) {
+	cmd := v.Shell()
+	cmd.SetStderr(v.stderr)
+	files := []string{v.Config.InstallationDir}
+	if preparer, ok := cmd.(shell.Preparer); ok {
+		startup, err := preparer.Prepare()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, startup...)
+	}
+	env := make(map[string]string)
+	for key, val := range prompt.Strip(cmd.GetEnvs()) {
+		env[key] = val
+	}
+	for _, key := range hostOnly {
+		delete(env, key)
+	}
+	paths := make([]string, 0)
+	for _, extension := range v.Extensions {
+		paths = append(paths, extension.Paths()...)
+	}
+	return &image.Image{
+		Base:       base,
+		Tag:        tag,
+		Files:      files,
+		Env:        env,
+		Path:       paths,
+		WorkingDir: v.Config.WorkingDir,
+		Entrypoint: append([]string{cmd.GetCommand()}, cmd.GetArgs()...),
+		CreatedBy:  fmt.Sprintf("dem %s build-image", v.Namespace),
+	}, nil
+}
+
+// Devcontainer returns a devcontainer configuration equivalent to the
+// workspace, toolchains are installed by the features of the extensions. It
+// also returns the extensions which have no features.
+func (v *Workspace) Devcontainer(img string) (*devcontainer.Config, []ext.Extension, error) {
+	config := &devcontainer.Config{
+		Name:         v.Namespace,
+		Image:        img,
+		Features:     make(map[string]map[string]interface{}),
+		ContainerEnv: v.Config.Workspace.Environment,
+	}
+	skipped := make([]ext.Extension, 0)
+	for _, extension := range v.Extensions {
+		featurer, ok := extension.(ext.Featurer)
+		if !ok {
+			skipped = append(skipped, extension)
+			continue
+		}
+		for _, feature := range featurer.Features() {
+			config.Features[feature.ID] = feature.Options
+		}
+	}
+	for _, s := range v.Config.Workspace.Ports {
+		port, err := netns.ParsePort(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		config.ForwardPorts = append(config.ForwardPorts, port.Target)
+	}
+	if program := v.Config.Workspace.Shell.Program; len(program) > 0 {
+		config.Customizations = map[string]interface{}{
+			"vscode": map[string]interface{}{
+				"settings": map[string]interface{}{
+					"terminal.integrated.defaultProfile.linux": filepath.Base(program),
+				},
+			},
+		}
+	}
+	return config, skipped, nil
+}
@@ -0,0 +1,60 @@
+package workspace
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/util/exec"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// HooksRecord returns the path of the record of the post-create hooks which
+// ran, the name cannot be taken by an extension
+func HooksRecord(config *workspaceconfig.Config) string {
+	return ext.StatePath(config, ".post_create")
+}
+
+// hooks returns the record of the post-create hooks, its version changes
+// with the commands
+func (v *Workspace) hooks() *ext.Installation {
+	h := sha256.New()
+	for _, command := range v.Config.Workspace.Hooks.PostCreate {
+		fmt.Fprintf(h, "%s\x00", command)
+	}
+	return &ext.Installation{
+		Record:  HooksRecord(v.Config),
+		Version: fmt.Sprintf("%x", h.Sum(nil)),
+	}
+}
+
+// postCreate runs the post-create hooks unless they already ran
+func (v *Workspace) postCreate(ctx context.Context) error {
+	hooks := v.Config.Workspace.Hooks
+	if hooks == nil || len(hooks.PostCreate) == 0 {
+		return nil
+	}
+	installation := v.hooks()
+	if installation.Recorded() {
+		return nil
+	}
+	env := v.Environment()
+	env["PATH"] = strings.Trim(env["EXT_PATH"]+":"+os.Getenv("PATH"), ":")
+	for _, command := range hooks.PostCreate {
+		fmt.Fprintf(v.stderr, "(%s) running post-create hook: %s\n", v.Namespace, command)
+		cmd := exec.New("/bin/sh", "-c", command)
+		cmd.SetDir(v.Config.WorkingDir)
+		cmd.SetEnv(env)
+		cmd.SetContext(ctx)
+		if v.stdout != nil {
+			cmd.SetStdout(v.stdout)
+		}
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("(%s) post-create hook '%s' failed, %v", v.Namespace, command, err)
+		}
+	}
+	return installation.Write(hooks.PostCreate)
+}
@@ -0,0 +1,89 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/util/homedir"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// MarkerFile links a directory to the workspace named in the file
+const MarkerFile = ".dem"
+
+// Link is a directory linked to a workspace
+type Link struct {
+	Namespace string
+	Dir       string
+	// Path is the marker file or the configuration listing the directory
+	Path string
+}
+
+// expandPath expands the variables and the home directory of a path of the
+// workspace configuration
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = filepath.Join(homedir.Dir(), path[1:])
+	}
+	return filepath.Clean(path)
+}
+
+// projects returns the links of the project directories of all workspaces
+func (v *Manager) projects() (map[string]*Link, error) {
+	namespaces, err := v.List()
+	if err != nil {
+		return nil, err
+	}
+	links := make(map[string]*Link)
+	for _, namespace := range namespaces {
+		path := v.configPath(namespace)
+		src, err := workspaceconfig.Read(path)
+		if err != nil {
+			continue
+		}
+		config, err := workspaceconfig.Parse(src)
+		if err != nil {
+			continue
+		}
+		for _, project := range config.Workspace.Projects {
+			dir := expandPath(project)
+			if _, ok := links[dir]; !ok && filepath.IsAbs(dir) {
+				links[dir] = &Link{Namespace: namespace, Dir: dir, Path: path}
+			}
+		}
+	}
+	return links, nil
+}
+
+// Find returns the link of the directory or of its nearest parent directory
+// which is linked to a workspace, by a marker file or as project directory. It
+// returns nil if no workspace is linked.
+func (v *Manager) Find(dir string) (*Link, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := v.projects()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		marker := filepath.Join(dir, MarkerFile)
+		if b, err := ioutil.ReadFile(marker); err == nil {
+			if fields := strings.Fields(string(b)); len(fields) > 0 {
+				return &Link{Namespace: fields[0], Dir: dir, Path: marker}, nil
+			}
+		}
+		if link, ok := projects[dir]; ok {
+			return link, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
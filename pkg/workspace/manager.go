@@ -0,0 +1,211 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/importer"
+	"github.com/samuelngs/dem/pkg/util/fs"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// Errors of operations on workspaces in the wrong state, test with errors.Is
+var (
+	ErrExist    = errors.New("already exists")
+	ErrNotExist = errors.New("does not exist")
+)
+
+// Error is an error of a workspace operation
+type Error struct {
+	Namespace string
+	Err       error
+}
+
+func (v *Error) Error() string {
+	return fmt.Sprintf("workspace '%s' %v", v.Namespace, v.Err)
+}
+
+func (v *Error) Unwrap() error {
+	return v.Err
+}
+
+// Manager creates, opens and deletes the workspaces in a storage directory.
+// It does not depend on the global configuration of the command line tool.
+type Manager struct {
+	StorageDir string
+	PluginsDir string
+	// Reporter reports the progress of extension setup tasks
+	Reporter ext.Reporter
+	// Stdout receives the output of post-create hooks, os.Stdout if nil
+	Stdout io.Writer
+	// Stderr receives warnings and the progress of post-create hooks,
+	// os.Stderr if nil
+	Stderr io.Writer
+	// Strict fails opening a workspace if any of its extensions fails to load
+	Strict bool
+}
+
+// New creates a manager for the workspaces in the storage directory, with
+// extensions loaded from the plugins directory. The progress of setup tasks
+// is not reported unless a Reporter is set.
+func New(storageDir, pluginsDir string) *Manager {
+	return &Manager{
+		StorageDir: storageDir,
+		PluginsDir: pluginsDir,
+		Reporter:   ext.NopReporter(),
+	}
+}
+
+func (v *Manager) dir(namespace string) string {
+	return filepath.Join(v.StorageDir, namespace)
+}
+
+func (v *Manager) configPath(namespace string) string {
+	return filepath.Join(v.dir(namespace), ".workspace.yaml")
+}
+
+func validate(namespace string) error {
+	if len(strings.TrimSpace(namespace)) == 0 || strings.ContainsAny(namespace, `/\`) || strings.HasPrefix(namespace, ".") {
+		return fmt.Errorf("invalid workspace name '%s'", namespace)
+	}
+	return nil
+}
+
+// Create creates a workspace with the default configuration
+func (v *Manager) Create(namespace string) error {
+	if err := validate(namespace); err != nil {
+		return err
+	}
+	if fs.Exists(v.configPath(namespace)) {
+		return &Error{namespace, ErrExist}
+	}
+	if err := fs.Mkdir(v.dir(namespace)); err != nil {
+		return err
+	}
+	b, err := workspaceconfig.New()
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(v.configPath(namespace), b)
+}
+
+// Import merges a configuration translated from another tool into the
+// configuration of a workspace
+func (v *Manager) Import(namespace string, imported *importer.Config) error {
+	if err := validate(namespace); err != nil {
+		return err
+	}
+	path := v.configPath(namespace)
+	if !fs.Exists(path) {
+		return &Error{namespace, ErrNotExist}
+	}
+	src, err := workspaceconfig.Read(path)
+	if err != nil {
+		return err
+	}
+	b, err := imported.Merge(src)
+	if err != nil {
+		return fmt.Errorf("(%s) unable to merge configuration, %v", namespace, err)
+	}
+	if _, err := workspaceconfig.Parse(b); err != nil {
+		return fmt.Errorf("(%s) imported configuration is invalid, %v", namespace, err)
+	}
+	return fs.WriteFile(path, b)
+}
+
+// Delete deletes a workspace, its files are kept if keepFiles is set and only
+// the configuration is removed
+func (v *Manager) Delete(namespace string, keepFiles bool) error {
+	if err := validate(namespace); err != nil {
+		return err
+	}
+	path := v.dir(namespace)
+	if keepFiles {
+		path = v.configPath(namespace)
+	}
+	if !fs.Exists(path) {
+		return &Error{namespace, ErrNotExist}
+	}
+	return os.Remove(path)
+}
+
+// List returns the namespaces of all workspaces
+func (v *Manager) List() ([]string, error) {
+	return workspaceconfig.List(v.StorageDir)
+}
+
+// Get opens a workspace and loads its extensions
+func (v *Manager) Get(namespace string) (*Workspace, error) {
+	if err := validate(namespace); err != nil {
+		return nil, err
+	}
+	if !fs.Exists(v.dir(namespace)) {
+		return nil, &Error{namespace, ErrNotExist}
+	}
+	config, err := workspaceconfig.Open(namespace, v.StorageDir, v.PluginsDir)
+	if err != nil {
+		return nil, err
+	}
+	exts, diagnostics, err := ext.Load(config)
+	if err != nil {
+		return nil, fmt.Errorf("(%s) %v", namespace, err)
+	}
+	if v.Strict && !diagnostics.Empty() {
+		return nil, fmt.Errorf("(%s) %v", namespace, diagnostics.Err())
+	}
+	ws := &Workspace{
+		Namespace:   namespace,
+		Config:      config,
+		Extensions:  exts,
+		Diagnostics: diagnostics,
+		reporter:    v.Reporter,
+		stdout:      v.Stdout,
+		stderr:      v.Stderr,
+	}
+	if ws.stderr == nil {
+		ws.stderr = os.Stderr
+	}
+	if ws.prompt, err = ws.Prompt(); err != nil {
+		return nil, fmt.Errorf("(%s) %v", namespace, err)
+	}
+	return ws, nil
+}
+
+// Environment returns the environment variables of the processes of a
+// workspace
+func (v *Manager) Environment(namespace string) (map[string]string, error) {
+	ws, err := v.Get(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return ws.Environment(), nil
+}
+
+// Setup installs the extensions of a workspace and runs its post-create hooks
+func (v *Manager) Setup(ctx context.Context, namespace string) error {
+	ws, err := v.Get(namespace)
+	if err != nil {
+		return err
+	}
+	return ws.Setup(ctx)
+}
+
+// Exec runs a command in a workspace, the workspace shell if no command is
+// given. The workspace is expected to be set up.
+func (v *Manager) Exec(ctx context.Context, namespace string, command ...string) error {
+	ws, err := v.Get(namespace)
+	if err != nil {
+		return err
+	}
+	cmd := ws.Shell()
+	if len(command) > 0 {
+		cmd = ws.Command(command[0], command[1:]...)
+	}
+	return ws.Exec(ctx, cmd)
+}
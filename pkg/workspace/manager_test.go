@@ -0,0 +1,57 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	manager := New(filepath.Join(dir, "workspaces"), filepath.Join(dir, "plugins"))
+
+	if err := manager.Create("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Create("test"); !errors.Is(err, ErrExist) {
+		t.Fatalf("Create of an existing workspace = %v, want %v", err, ErrExist)
+	}
+	if namespaces, err := manager.List(); err != nil || len(namespaces) != 1 || namespaces[0] != "test" {
+		t.Fatalf("List = %v, %v", namespaces, err)
+	}
+	env, err := manager.Environment("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env[WorkspaceKey] != "test" || env["HOME"] != filepath.Join(dir, "workspaces", "test") {
+		t.Errorf("Environment = %v", env)
+	}
+	if err := manager.Setup(context.Background(), "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Exec(context.Background(), "test", "touch", "marker"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "workspaces", "test", "marker")); err != nil {
+		t.Errorf("command did not run in the workspace directory, %v", err)
+	}
+
+	// the files of the workspace are kept
+	if err := manager.Delete("test", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Get("test"); err != nil {
+		t.Errorf("workspace directory was removed, %v", err)
+	}
+	if err := manager.Delete("test", true); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Delete of a deleted workspace = %v, want %v", err, ErrNotExist)
+	}
+	if _, err := manager.Environment("missing"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Environment of a missing workspace = %v, want %v", err, ErrNotExist)
+	}
+	if err := manager.Create("../escape"); err == nil {
+		t.Errorf("invalid name was accepted")
+	}
+}
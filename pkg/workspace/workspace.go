@@ -0,0 +1,248 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/samuelngs/dem/pkg/ext"
+	"github.com/samuelngs/dem/pkg/prompt"
+	"github.com/samuelngs/dem/pkg/shell"
+	"github.com/samuelngs/dem/pkg/shell/dotfiles"
+	"github.com/samuelngs/dem/pkg/util/cgroup"
+	"github.com/samuelngs/dem/pkg/util/env"
+	"github.com/samuelngs/dem/pkg/util/envcomposer"
+	"github.com/samuelngs/dem/pkg/util/exec"
+	"github.com/samuelngs/dem/pkg/util/homedir"
+	"github.com/samuelngs/dem/pkg/util/netns"
+	"github.com/samuelngs/dem/pkg/workspaceconfig"
+)
+
+// SessionKey is set in the environment of workspace processes
+const SessionKey = "CWKS"
+
+// Workspace is an opened workspace with its loaded extensions
+type Workspace struct {
+	Namespace   string
+	Config      *workspaceconfig.Config
+	Extensions  []ext.Extension
+	Diagnostics *ext.Diagnostics
+	reporter    ext.Reporter
+	stdout      io.Writer
+	stderr      io.Writer
+	prompt      *prompt.Shell
+}
+
+// WorkspaceKey is set to the namespace in the environment of workspace
+// processes and activated shells, for prompt frameworks
+const WorkspaceKey = "DEM_WORKSPACE"
+
+// Environment returns the environment variables of workspace processes
+func (v *Workspace) Environment() map[string]string {
+	config := v.Config
+	envcomposer := envcomposer.New()
+
+	// fixes issue where backspace behaves strangely with zsh
+	envcomposer.Set("TERM", env.GetEnvAsString("TERM", "xterm"))
+	envcomposer.Set("SHELL", config.Workspace.Shell.Program)
+	// maps virtual user to shell
+	envcomposer.Set("USER", v.Namespace)
+	envcomposer.Set("HOME", config.WorkingDir)
+	envcomposer.Set("UNMASK_HOME", homedir.Dir())
+	envcomposer.Set("PS1", fmt.Sprintf("(%s) $ ", v.Namespace))
+	envcomposer.Set(SessionKey, "1")
+	envcomposer.Set(WorkspaceKey, v.Namespace)
+	envcomposer.Set("HISTFILE", v.history())
+	if v.prompt != nil {
+		envcomposer.Set("PS1", v.prompt.Plain)
+		for key, val := range v.prompt.Vars() {
+			envcomposer.Set(key, val)
+		}
+	}
+	// attempts to fix terminal copy and paste issue, it also
+	// fixes X11 compatibility issue.
+	envcomposer.Set("DISPLAY", env.GetEnvAsString("DISPLAY", ":0.0"))
+
+	paths := v.compose(envcomposer)
+	envcomposer.Set("EXT_PATH", strings.Join(paths, ":"))
+	return envcomposer.AsMap()
+}
+
+// history returns the history file of the workspace shell
+func (v *Workspace) history() string {
+	shell := v.Config.Workspace.Shell
+	if len(shell.History) == 0 {
+		return filepath.Join(v.Config.WorkingDir, fmt.Sprintf(".%s_history", filepath.Base(shell.Program)))
+	}
+	path := expandPath(shell.History)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(v.Config.WorkingDir, path)
+	}
+	return path
+}
+
+// compose sets the variables of the workspace configuration and extensions,
+// it returns the bin paths of the extensions
+func (v *Workspace) compose(envcomposer envcomposer.Composer) []string {
+	for key, val := range v.Config.Workspace.Environment {
+		envcomposer.Set(key, val)
+	}
+	paths := make([]string, 0)
+	for _, ext := range v.Extensions {
+		for key, val := range ext.Environment() {
+			envcomposer.Set(key, val)
+		}
+		paths = append(paths, ext.Paths()...)
+	}
+	return paths
+}
+
+// Activation returns the environment variables exported into the shell of
+// the user when it enters a linked directory. Variables of workspace sessions
+// (e.g HOME, USER) are not included, and the extension paths are prepended
+// to path, the PATH of the shell.
+func (v *Workspace) Activation(path string) map[string]string {
+	envcomposer := envcomposer.New()
+	paths := v.compose(envcomposer)
+	if len(path) > 0 {
+		paths = append(paths, path)
+	}
+	envcomposer.Set("PATH", strings.Join(paths, ":"))
+	envcomposer.Set(WorkspaceKey, v.Namespace)
+	return envcomposer.AsMap()
+}
+
+// Prompt renders the prompt of the workspace shell
+func (v *Workspace) Prompt() (*prompt.Shell, error) {
+	conf := v.Config.Workspace.Prompt
+	if conf == nil {
+		conf = new(workspaceconfig.Prompt)
+	}
+	text := conf.Template
+	if len(text) == 0 {
+		text = prompt.DefaultTemplate
+	}
+	mode := conf.Mode
+	switch mode {
+	case "":
+		mode = prompt.Replace
+	case prompt.Replace, prompt.Prepend:
+	default:
+		return nil, fmt.Errorf("invalid prompt mode '%s' (%s, %s)", mode, prompt.Replace, prompt.Prepend)
+	}
+	toolchains := make([]string, len(v.Extensions))
+	for i, extension := range v.Extensions {
+		toolchains[i] = extension.String()
+	}
+	data := prompt.NewData(v.Namespace, toolchains)
+	p, err := prompt.Render(text, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template, %v", err)
+	}
+	plain, _ := prompt.Render(text, data, false)
+	title, err := prompt.Render(conf.Title, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt title template, %v", err)
+	}
+	return &prompt.Shell{Prompt: p, Plain: plain, Title: title, Mode: mode}, nil
+}
+
+// Aliases returns the shell aliases of the workspace and its extensions
+func (v *Workspace) Aliases() map[string]string {
+	aliases := make(map[string]string)
+	for alias, cmd := range v.Config.Workspace.Aliases {
+		aliases[alias] = cmd
+	}
+	for _, ext := range v.Extensions {
+		for alias, cmd := range ext.Aliases() {
+			aliases[alias] = cmd
+		}
+	}
+	return aliases
+}
+
+// Setup runs the setup tasks of the workspace extensions and then the
+// post-create hooks, pending tasks are cancelled once the context is done or
+// once they exceed the setup timeout of the configuration
+func (v *Workspace) Setup(ctx context.Context) error {
+	ctx = ext.WithTimeout(ctx, v.Config.Workspace.Setup.TaskTimeout())
+	if err := ext.Setup(ctx, v.reporter, v.Extensions...); err != nil {
+		return err
+	}
+	return v.postCreate(ctx)
+}
+
+// limit returns the wrapper starting commands in the cgroup of the workspace,
+// nil if the workspace has no resource limits
+func (v *Workspace) limit() (exec.Wrapper, error) {
+	resources := v.Config.Workspace.Resources
+	if resources == nil {
+		return nil, nil
+	}
+	limits := cgroup.Limits{
+		MemoryMax: resources.MemoryMax,
+		CPUWeight: resources.CPUWeight,
+		CPUQuota:  resources.CPUQuota,
+		PidsMax:   resources.PidsMax,
+	}
+	return cgroup.New(v.Namespace, limits)
+}
+
+// Command returns a command running in the workspace directory with the
+// workspace environment
+func (v *Workspace) Command(program string, args ...string) exec.Command {
+	cmd := shell.New(program, args...)
+	if dotfiler, ok := cmd.(shell.Dotfiler); ok {
+		dotfiler.SetDotfiles(dotfiles.New(v.Config.Workspace.Shell.Dotfiles, homedir.Dir(), v.Config.WorkingDir))
+	}
+	cmd.SetDir(v.Config.WorkingDir)
+	cmd.SetEnv(v.Environment())
+	cmd.SetAliases(v.Aliases())
+	return cmd
+}
+
+// Shell returns the command of the workspace shell
+func (v *Workspace) Shell() exec.Command {
+	shell := v.Config.Workspace.Shell
+	return v.Command(shell.Program, shell.Args...)
+}
+
+// Exec runs a command of the workspace, in its own network namespace if the
+// workspace is isolated and in a cgroup of the workspace if it has resource
+// limits. Limits which cannot be applied are reported as a warning and the
+// command runs without them. The command is killed once the context is done.
+func (v *Workspace) Exec(ctx context.Context, cmd exec.Command) error {
+	wrappers := make([]exec.Wrapper, 0)
+	if v.Config.Workspace.Network == workspaceconfig.NetworkIsolated {
+		wrapper, err := isolate(v.Config.Workspace.Ports)
+		if err != nil {
+			return fmt.Errorf("(%s) unable to isolate network, %v", v.Namespace, err)
+		}
+		wrappers = append(wrappers, wrapper)
+	}
+	limits, err := v.limit()
+	if err != nil {
+		fmt.Fprintf(v.stderr, "warning: resource limits are not applied, %v\n", err)
+	} else if limits != nil {
+		wrappers = append(wrappers, limits)
+	}
+	if len(wrappers) > 0 {
+		cmd.SetWrapper(exec.Chain(wrappers...))
+	}
+	cmd.SetContext(ctx)
+	return cmd.Run()
+}
+
+func isolate(ports []string) (exec.Wrapper, error) {
+	forwards := make([]netns.Port, len(ports))
+	for i, s := range ports {
+		port, err := netns.ParsePort(s)
+		if err != nil {
+			return nil, err
+		}
+		forwards[i] = port
+	}
+	return netns.New(forwards...)
+}
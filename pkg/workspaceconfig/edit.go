@@ -0,0 +1,56 @@
+package workspaceconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// keyLine matches a block mapping entry, e.g `  version: 1.21.3 # comment`
+var keyLine = regexp.MustCompile(`^( *)([A-Za-z0-9_][A-Za-z0-9_.-]*|"[^"]*"|'[^']*'):( *)(.*)$`)
+
+// Set replaces the scalar value at the key path of a yaml document. The
+// document is edited line by line, so comments and formatting of the rest of
+// the document are kept. Only block mappings are supported.
+func Set(src []byte, value string, path ...string) ([]byte, error) {
+	lines := strings.Split(string(src), "\n")
+	depth, parent, child := 0, -1, -1
+	for i, line := range lines {
+		m := keyLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		indent := len(m[1])
+		if indent <= parent {
+			break
+		}
+		if child < 0 {
+			child = indent
+		}
+		if indent != child || strings.Trim(m[2], `"'`) != path[depth] {
+			continue
+		}
+		if depth < len(path)-1 {
+			depth, parent, child = depth+1, indent, -1
+			continue
+		}
+		old, comment := m[4], ""
+		if n := strings.Index(old, " #"); n >= 0 {
+			old, comment = old[:n], old[n:]
+		}
+		trimmed := strings.TrimRight(old, " ")
+		if len(trimmed) == 0 {
+			return nil, fmt.Errorf("%s is not a scalar value", strings.Join(path, "."))
+		}
+		if q := trimmed[:1]; q == `"` || q == `'` {
+			value = q + value + q
+		}
+		sep := m[3]
+		if len(sep) == 0 {
+			sep = " "
+		}
+		lines[i] = m[1] + m[2] + ":" + sep + value + old[len(trimmed):] + comment
+		return []byte(strings.Join(lines, "\n")), nil
+	}
+	return nil, fmt.Errorf("unable to find %s in configuration", strings.Join(path, "."))
+}
@@ -1,19 +1,28 @@
 package workspaceconfig
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/samuelngs/dem/pkg/util/env"
 	"gopkg.in/yaml.v2"
 )
 
+// NetworkIsolated runs the workspace in its own network namespace
+const NetworkIsolated = "isolated"
+
 // Config is the root of configuration
 type Config struct {
 	Namespace       string     `yaml:"-"`
 	WorkingDir      string     `yaml:"-"`
+	ConfigPath      string     `yaml:"-"`
 	PluginsDir      string     `yaml:"-"`
 	InstallationDir string     `yaml:"-"`
+	CacheDir        string     `yaml:"-"`
+	LockfilePath    string     `yaml:"-"`
 	Src             []byte     `yaml:"-"`
 	Workspace       *Workspace `yaml:"workspace"`
 }
@@ -24,12 +33,133 @@ type Workspace struct {
 	Aliases     map[string]string      `yaml:"aliases"`
 	Shell       *Shell                 `yaml:"shell"`
 	With        map[string]interface{} `yaml:"with"`
+	Resources   *Resources             `yaml:"resources,omitempty"`
+	Network     string                 `yaml:"network,omitempty"`
+	Ports       []string               `yaml:"ports,omitempty"`
+	Hooks       *Hooks                 `yaml:"hooks,omitempty"`
+	Prompt      *Prompt                `yaml:"prompt,omitempty"`
+	Setup       *Setup                 `yaml:"setup,omitempty"`
+	// project directories linked to the workspace, the workspace environment
+	// is activated in them by the shell hook
+	Projects []string `yaml:"projects,omitempty"`
 }
 
 // Shell configuration
 type Shell struct {
-	Program string   `yaml:"program"`
-	Args    []string `yaml:"args"`
+	Program  string    `yaml:"program"`
+	Args     []string  `yaml:"args"`
+	Dotfiles *Dotfiles `yaml:"dotfiles,omitempty"`
+	// history file, relative to the workspace directory. It defaults to a
+	// file of the shell in the workspace directory (e.g .zsh_history).
+	History string `yaml:"history,omitempty"`
+}
+
+// Dotfiles policies
+const (
+	// DotfilesInherit loads the dotfiles of the home of the user
+	DotfilesInherit = "inherit"
+	// DotfilesIsolated loads the dotfiles of the workspace directory
+	DotfilesIsolated = "isolated"
+)
+
+// Dotfiles is the policy of the dotfiles loaded by the workspace shell, it is
+// either a policy name or a list of files of the home of the user which are
+// linked (or copied) into the workspace directory:
+//
+//	dotfiles: inherit
+//	dotfiles: [.gitconfig, .vimrc, {path: .bashrc, copy: true}]
+type Dotfiles struct {
+	Policy string
+	Files  []*Dotfile
+}
+
+// Dotfile is a file or directory of the home of the user, relative to it
+type Dotfile struct {
+	Path string `yaml:"path"`
+	// copy the file once instead of linking it, so that it can be changed
+	// in the workspace
+	Copy bool `yaml:"copy,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (v *Dotfiles) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var policy string
+	if err := unmarshal(&policy); err == nil {
+		if policy != DotfilesInherit && policy != DotfilesIsolated {
+			return fmt.Errorf("invalid dotfiles policy '%s' (%s, %s)", policy, DotfilesInherit, DotfilesIsolated)
+		}
+		v.Policy = policy
+		return nil
+	}
+	v.Policy = DotfilesIsolated
+	return unmarshal(&v.Files)
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (v *Dotfiles) MarshalYAML() (interface{}, error) {
+	if len(v.Files) > 0 {
+		return v.Files, nil
+	}
+	return v.Policy, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (v *Dotfile) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&v.Path); err == nil {
+		return nil
+	}
+	type dotfile Dotfile
+	return unmarshal((*dotfile)(v))
+}
+
+// Hooks are shell commands run at events of the workspace lifecycle
+type Hooks struct {
+	// commands run once in the workspace directory after the first
+	// successful setup, they run again once the commands are changed
+	PostCreate []string `yaml:"post_create,omitempty"`
+}
+
+// Setup configuration of the extension setup tasks
+type Setup struct {
+	// duration after which a setup task is cancelled (e.g 10m), tasks are
+	// not cancelled if empty
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// TaskTimeout returns the timeout of setup tasks, zero if none is configured
+func (v *Setup) TaskTimeout() time.Duration {
+	if v == nil || v.Timeout == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(v.Timeout)
+	return d
+}
+
+// Prompt configuration of the workspace shell, templates are text/template
+// templates (see package prompt)
+type Prompt struct {
+	// prompt template, e.g `({{.Namespace}}{{branch " %s"}}) $ `
+	Template string `yaml:"template,omitempty"`
+	// replace (default) or prepend to the prompt of the user
+	Mode string `yaml:"mode,omitempty"`
+	// terminal title template, the title is not set if empty
+	Title string `yaml:"title,omitempty"`
+}
+
+// Resources configuration, limits are applied to the workspace processes
+// through a cgroup v2 slice of the systemd user manager
+type Resources struct {
+	// maximum memory usage, in bytes or with K, M, G suffix (e.g 2G)
+	MemoryMax string `yaml:"memory_max,omitempty"`
+
+	// relative cpu weight in the range of [1, 10000]
+	CPUWeight int `yaml:"cpu_weight,omitempty"`
+
+	// cpu bandwidth quota as percentage of a single cpu (e.g 150%)
+	CPUQuota string `yaml:"cpu_quota,omitempty"`
+
+	// maximum number of processes
+	PidsMax int `yaml:"pids_max,omitempty"`
 }
 
 // DefaultConfiguration returns default configuration
@@ -67,9 +197,60 @@ func Parse(dat []byte) (*Config, error) {
 	if err := yaml.Unmarshal(dat, conf); err != nil {
 		return nil, err
 	}
+	if setup := conf.Workspace.Setup; setup != nil && setup.Timeout != "" {
+		if d, err := time.ParseDuration(setup.Timeout); err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid setup timeout '%s'", setup.Timeout)
+		}
+	}
 	return conf, nil
 }
 
+// Open reads and parses the configuration of a workspace, and resolves the
+// workspace directories
+func Open(namespace, storageDir, pluginsDir string) (*Config, error) {
+	workingDir := fmt.Sprintf("%s/%s", storageDir, namespace)
+	if _, err := os.Stat(workingDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("workspace '%s' does not exist", namespace)
+	}
+
+	configPath := fmt.Sprintf("%s/%s", workingDir, ".workspace.yaml")
+
+	yaml, err := Read(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("(%s) unable to read YAML configuration", namespace)
+	}
+
+	config, err := Parse(yaml)
+	if err != nil {
+		return nil, fmt.Errorf("(%s) unable to parse YAML configuration, %v", namespace, err)
+	}
+	config.Namespace = namespace
+	config.WorkingDir = workingDir
+	config.ConfigPath = configPath
+	config.PluginsDir = pluginsDir
+	config.InstallationDir = filepath.Join(workingDir, ".installation")
+	config.CacheDir = filepath.Join(storageDir, ".cache")
+	config.LockfilePath = filepath.Join(workingDir, ".workspace.lock")
+	config.Src = yaml
+	return config, nil
+}
+
+// List returns the namespaces of the workspaces in the storage directory
+func List(storageDir string) ([]string, error) {
+	files, err := ioutil.ReadDir(storageDir)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, 0)
+	for _, file := range files {
+		configPath := fmt.Sprintf("%s/%s/%s", storageDir, file.Name(), ".workspace.yaml")
+		if file.IsDir() && IsValid(configPath) {
+			namespaces = append(namespaces, file.Name())
+		}
+	}
+	return namespaces, nil
+}
+
 // IsValid validates yaml configuration
 func IsValid(cfgPath string) bool {
 	dat, err := Read(cfgPath)